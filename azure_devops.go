@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// azureDevOpsURL is used when no Azure DevOps Server URL has been configured
+	azureDevOpsURL = "https://dev.azure.com"
+	// azureDevOpsAPIVersion is the version of the REST API that the provider has been written against
+	azureDevOpsAPIVersion = "7.0"
+	// azureDevOpsPageSize is the number of pull requests requested per page
+	azureDevOpsPageSize = 100
+)
+
+func init() {
+	RegisterProvider(newAzureDevOpsProvider)
+}
+
+// AzureDevOpsProvider fetches pull requests from Azure DevOps Repos
+type AzureDevOpsProvider struct {
+	URL          string
+	Token        string
+	Organisation string
+	Projects     []string
+	Repos        []string
+}
+
+func newAzureDevOpsProvider(conf *Config) []Provider {
+	if len(conf.AzureDevOpsProjects) == 0 && len(conf.AzureDevOpsRepos) == 0 {
+		return nil
+	}
+	provider := &AzureDevOpsProvider{
+		URL:          strings.TrimSuffix(conf.AzureDevOpsURL, "/"),
+		Token:        conf.AzureDevOpsToken,
+		Organisation: conf.AzureDevOpsOrganisation,
+		Projects:     conf.AzureDevOpsProjects,
+		Repos:        conf.AzureDevOpsRepos,
+	}
+	if provider.URL == "" {
+		provider.URL = azureDevOpsURL
+	}
+	return []Provider{provider}
+}
+
+// Name returns the name of the provider
+func (p *AzureDevOpsProvider) Name() string {
+	return "Azure DevOps"
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (p *AzureDevOpsProvider) Validate() []error {
+	var errors []error
+	if p.Organisation == "" {
+		errors = append(errors, fmt.Errorf("Azure DevOps organisation cannot be empty"))
+	}
+	if p.Token == "" {
+		errors = append(errors, fmt.Errorf("Azure DevOps token cannot be empty"))
+	}
+	for _, repoName := range p.Repos {
+		if len(strings.Split(repoName, "/")) != 2 {
+			errors = append(errors, fmt.Errorf("%s is not a valid Azure DevOps repository", repoName))
+		}
+	}
+	return errors
+}
+
+type azureDevOpsRepositories struct {
+	Value []struct {
+		Name       string `json:"name"`
+		IsDisabled bool   `json:"isDisabled"`
+	} `json:"value"`
+}
+
+type azureDevOpsPullRequests struct {
+	Value []struct {
+		PullRequestID int    `json:"pullRequestId"`
+		Title         string `json:"title"`
+		IsDraft       bool   `json:"isDraft"`
+		CreatedBy     struct {
+			UniqueName string `json:"uniqueName"`
+		} `json:"createdBy"`
+		CreationDate time.Time `json:"creationDate"`
+		Reviewers    []struct {
+			// Vote is 10 for approved, 5 for approved with suggestions, 0 for no vote, -5 for
+			// waiting for author and -10 for rejected
+			Vote int `json:"vote"`
+		} `json:"reviewers"`
+		Repository struct {
+			WebURL string `json:"webUrl"`
+		} `json:"repository"`
+	} `json:"value"`
+	Count int `json:"count"`
+}
+
+// Fetch returns a channel that emits all active pull requests from the configured projects and
+// repositories
+func (p *AzureDevOpsProvider) Fetch(ctx context.Context, log Logger, errs *FetchErrors) (<-chan *PullRequest, error) {
+	out := make(chan *PullRequest)
+
+	// create a sync group that is used to close the out channel when all azure devops repos has
+	// been trawled
+	var wg sync.WaitGroup
+
+	// personal access tokens are sent as the password with an empty username
+	client := &http.Client{Transport: &basicAuthTransport{Password: p.Token}}
+
+	var repos []string
+
+	for _, project := range p.Projects {
+		result := &azureDevOpsRepositories{}
+		endpoint := fmt.Sprintf("%s/%s/%s/_apis/git/repositories?api-version=%s", p.URL, url.PathEscape(p.Organisation), url.PathEscape(project), azureDevOpsAPIVersion)
+		if err := getJSON(ctx, client, endpoint, result); err != nil {
+			errs.Add(p.Name(), fmt.Sprintf("%s (project)", project), err)
+			continue
+		}
+		for _, repo := range result.Value {
+			if !repo.IsDisabled {
+				repos = append(repos, fmt.Sprintf("%s/%s", project, repo.Name))
+			}
+		}
+	}
+
+	repos = append(repos, p.Repos...)
+
+	// spin out each request to find PRs on a repo into a separate goroutine
+	for _, repo := range deduplicate(repos) {
+		wg.Add(1)
+
+		go func(repoName string) {
+			defer wg.Done()
+			log.Debugf("fetching Azure DevOps PRs for %s\n", repoName)
+
+			parts := strings.Split(repoName, "/")
+
+			for skip := 0; ; skip += azureDevOpsPageSize {
+				query := url.Values{}
+				query.Set("searchCriteria.status", "active")
+				query.Set("$top", fmt.Sprintf("%d", azureDevOpsPageSize))
+				query.Set("$skip", fmt.Sprintf("%d", skip))
+				query.Set("api-version", azureDevOpsAPIVersion)
+				endpoint := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/pullrequests?%s", p.URL, url.PathEscape(p.Organisation), url.PathEscape(parts[0]), url.PathEscape(parts[1]), query.Encode())
+
+				result := &azureDevOpsPullRequests{}
+				if err := getJSON(ctx, client, endpoint, result); err != nil {
+					errs.Add(p.Name(), repoName, err)
+					return
+				}
+
+				for _, pr := range result.Value {
+					requiresChanges := false
+					approved := false
+					for _, reviewer := range pr.Reviewers {
+						if reviewer.Vote < 0 {
+							requiresChanges = true
+						}
+						if reviewer.Vote > 0 {
+							approved = true
+						}
+					}
+					out <- &PullRequest{
+						ID:     pr.PullRequestID,
+						Author: pr.CreatedBy.UniqueName,
+						// Azure DevOps doesn't track when a pull request was last updated
+						Updated:         pr.CreationDate,
+						WebLink:         fmt.Sprintf("%s/pullrequest/%d", pr.Repository.WebURL, pr.PullRequestID),
+						Title:           pr.Title,
+						Repository:      repoName,
+						RequiresChanges: requiresChanges,
+						Approved:        approved && !requiresChanges,
+						Draft:           pr.IsDraft,
+					}
+				}
+
+				if result.Count < azureDevOpsPageSize {
+					return
+				}
+			}
+		}(repo)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
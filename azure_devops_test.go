@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureDevOpsProvider_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pass, _ := r.BasicAuth(); pass != "secret" {
+			t.Errorf("expected personal access token as password, got '%s'", pass)
+		}
+		switch r.URL.Path {
+		case "/acme/project/_apis/git/repositories":
+			fmt.Fprint(w, `{"value": [{"name": "one"}, {"name": "disabled", "isDisabled": true}], "count": 2}`)
+		case "/acme/project/_apis/git/repositories/one/pullrequests":
+			if r.URL.Query().Get("searchCriteria.status") != "active" {
+				t.Errorf("expected only active pull requests to be requested")
+			}
+			fmt.Fprint(w, `{"value": [
+				{"pullRequestId": 1, "title": "approved", "createdBy": {"uniqueName": "jane@example.com"}, "creationDate": "2022-10-03T08:12:34Z",
+				 "reviewers": [{"vote": 10}, {"vote": 0}], "repository": {"webUrl": "https://dev.azure.com/acme/project/_git/one"}},
+				{"pullRequestId": 2, "title": "waiting", "createdBy": {"uniqueName": "john@example.com"}, "creationDate": "2022-10-03T08:12:34Z",
+				 "reviewers": [{"vote": 5}, {"vote": -5}]},
+				{"pullRequestId": 3, "title": "rejected draft", "isDraft": true, "reviewers": [{"vote": -10}]}
+			], "count": 3}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider := &AzureDevOpsProvider{
+		URL:          server.URL,
+		Token:        "secret",
+		Organisation: "acme",
+		Projects:     []string{"project"},
+	}
+
+	prs := fetchAll(t, provider)
+	if len(prs) != 3 {
+		t.Fatalf("expected 3 pull requests, got %d", len(prs))
+	}
+
+	if prs[1].Author != "jane@example.com" || prs[1].Repository != "project/one" || prs[1].WebLink != "https://dev.azure.com/acme/project/_git/one/pullrequest/1" {
+		t.Errorf("unexpected first pull request %+v", prs[1])
+	}
+	if !prs[1].Approved || prs[1].RequiresChanges {
+		t.Errorf("expected first pull request to be approved, got %+v", prs[1])
+	}
+	if prs[2].Approved || !prs[2].RequiresChanges {
+		t.Errorf("expected second pull request to be waiting for the author, got %+v", prs[2])
+	}
+	if !prs[3].Draft || !prs[3].RequiresChanges {
+		t.Errorf("expected third pull request to be a rejected draft, got %+v", prs[3])
+	}
+}
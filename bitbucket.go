@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// bitbucketCloudAPI is the API used when no Bitbucket Server URL has been configured
+const bitbucketCloudAPI = "https://api.bitbucket.org/2.0"
+
+func init() {
+	RegisterProvider(newBitbucketProvider)
+}
+
+// BitbucketProvider fetches pull requests from Bitbucket Cloud or, if an URL is configured, from a
+// Bitbucket Server
+type BitbucketProvider struct {
+	URL      string
+	Username string
+	Token    string
+	Repos    []string
+
+	// cloudAPI is the base URL for the Bitbucket Cloud API
+	cloudAPI string
+}
+
+func newBitbucketProvider(conf *Config) []Provider {
+	if len(conf.BitbucketRepos) == 0 {
+		return nil
+	}
+	return []Provider{&BitbucketProvider{
+		URL:      strings.TrimSuffix(conf.BitbucketURL, "/"),
+		Username: conf.BitbucketUsername,
+		Token:    conf.BitbucketToken,
+		Repos:    conf.BitbucketRepos,
+		cloudAPI: bitbucketCloudAPI,
+	}}
+}
+
+// Name returns the name of the provider
+func (p *BitbucketProvider) Name() string {
+	return "Bitbucket"
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (p *BitbucketProvider) Validate() []error {
+	var errors []error
+	for _, repoName := range p.Repos {
+		if len(strings.Split(repoName, "/")) != 2 {
+			errors = append(errors, fmt.Errorf("%s is not a valid Bitbucket repository", repoName))
+		}
+	}
+	return errors
+}
+
+// Fetch returns a channel that emits all open pull requests from the configured repositories
+func (p *BitbucketProvider) Fetch(ctx context.Context, log Logger, errs *FetchErrors) (<-chan *PullRequest, error) {
+	out := make(chan *PullRequest)
+
+	// create a sync group that is used to close the out channel when all bitbucket repos has been
+	// trawled
+	var wg sync.WaitGroup
+
+	client := p.client()
+
+	// spin out each request to find PRs on a repo into a separate goroutine
+	for _, repo := range p.Repos {
+		wg.Add(1)
+
+		go func(repoName string) {
+			defer wg.Done()
+			log.Debugf("fetching Bitbucket PRs for %s\n", repoName)
+
+			var err error
+			if p.URL == "" {
+				err = p.fetchCloud(ctx, client, repoName, out)
+			} else {
+				err = p.fetchServer(ctx, client, repoName, out)
+			}
+			if err != nil {
+				errs.Add(p.Name(), repoName, err)
+			}
+		}(repo)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+// client returns a HTTP client that authenticates with an app password if a username has been
+// configured, otherwise with a HTTP access token
+func (p *BitbucketProvider) client() *http.Client {
+	if p.Username != "" {
+		return &http.Client{Transport: &basicAuthTransport{Username: p.Username, Password: p.Token}}
+	}
+	if p.Token != "" {
+		return newTokenClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: p.Token}))
+	}
+	return defaultClient
+}
+
+type bitbucketCloudPage struct {
+	Values []struct {
+		ID     int    `json:"id"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Author struct {
+			Nickname string `json:"nickname"`
+		} `json:"author"`
+		UpdatedOn    time.Time `json:"updated_on"`
+		Participants []struct {
+			Role  string `json:"role"`
+			State string `json:"state"`
+		} `json:"participants"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+// fetchCloud sends all open pull requests of a Bitbucket Cloud repository to out
+func (p *BitbucketProvider) fetchCloud(ctx context.Context, client *http.Client, repoName string, out chan<- *PullRequest) error {
+	query := url.Values{}
+	query.Set("state", "OPEN")
+	query.Set("fields", "+values.participants")
+	next := fmt.Sprintf("%s/repositories/%s/pullrequests?%s", p.cloudAPI, repoName, query.Encode())
+
+	// the Bitbucket Cloud API returns the URL to the next page of results until there are no more
+	for next != "" {
+		page := &bitbucketCloudPage{}
+		if err := getJSON(ctx, client, next, page); err != nil {
+			return err
+		}
+		for _, pr := range page.Values {
+			var states []string
+			for _, participant := range pr.Participants {
+				if participant.Role == "REVIEWER" {
+					states = append(states, participant.State)
+				}
+			}
+			requiresChanges, approved := bitbucketReviewState(states, "changes_requested", "approved")
+			out <- &PullRequest{
+				ID:              pr.ID,
+				Author:          pr.Author.Nickname,
+				Updated:         pr.UpdatedOn,
+				WebLink:         pr.Links.HTML.Href,
+				Title:           pr.Title,
+				Repository:      repoName,
+				RequiresChanges: requiresChanges,
+				Approved:        approved,
+				Draft:           pr.Draft,
+			}
+		}
+		next = page.Next
+	}
+	return nil
+}
+
+type bitbucketServerPage struct {
+	Values []struct {
+		ID     int    `json:"id"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Author struct {
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"author"`
+		// UpdatedDate is in milliseconds since the unix epoch
+		UpdatedDate int64 `json:"updatedDate"`
+		Reviewers   []struct {
+			Status string `json:"status"`
+		} `json:"reviewers"`
+		Links struct {
+			Self []struct {
+				Href string `json:"href"`
+			} `json:"self"`
+		} `json:"links"`
+	} `json:"values"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+// fetchServer sends all open pull requests of a Bitbucket Server repository to out
+func (p *BitbucketProvider) fetchServer(ctx context.Context, client *http.Client, repoName string, out chan<- *PullRequest) error {
+	parts := strings.Split(repoName, "/")
+
+	start := 0
+	for {
+		query := url.Values{}
+		query.Set("state", "OPEN")
+		query.Set("start", fmt.Sprintf("%d", start))
+		endpoint := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests?%s", p.URL, parts[0], parts[1], query.Encode())
+
+		page := &bitbucketServerPage{}
+		if err := getJSON(ctx, client, endpoint, page); err != nil {
+			return err
+		}
+		for _, pr := range page.Values {
+			var states []string
+			for _, reviewer := range pr.Reviewers {
+				states = append(states, reviewer.Status)
+			}
+			requiresChanges, approved := bitbucketReviewState(states, "NEEDS_WORK", "APPROVED")
+			pullRequest := &PullRequest{
+				ID:              pr.ID,
+				Author:          pr.Author.User.Name,
+				Updated:         time.Unix(0, pr.UpdatedDate*int64(time.Millisecond)),
+				Title:           pr.Title,
+				Repository:      repoName,
+				RequiresChanges: requiresChanges,
+				Approved:        approved,
+				Draft:           pr.Draft,
+			}
+			if len(pr.Links.Self) > 0 {
+				pullRequest.WebLink = pr.Links.Self[0].Href
+			}
+			out <- pullRequest
+		}
+
+		if page.IsLastPage {
+			return nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+// bitbucketReviewState returns requiresChanges and approved flags from the reviewers' states. Bitbucket
+// doesn't say in which order the reviews were made, so any reviewer requesting changes takes
+// precedence over an approval.
+func bitbucketReviewState(states []string, changesRequested, approvedState string) (bool, bool) {
+	requiresChanges := false
+	approved := false
+	for _, state := range states {
+		if state == changesRequested {
+			requiresChanges = true
+		}
+		if state == approvedState {
+			approved = true
+		}
+	}
+	return requiresChanges, approved && !requiresChanges
+}
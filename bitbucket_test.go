@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBitbucketProvider_FetchCloud(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repositories/workspace/repo/pullrequests" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if user, pass, _ := r.BasicAuth(); user != "john" || pass != "secret" {
+			t.Errorf("expected basic auth john:secret, got %s:%s", user, pass)
+		}
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprintf(w, `{"values": [
+				{"id": 1, "title": "first", "author": {"nickname": "jane"}, "updated_on": "2022-10-03T08:12:34.123456+00:00",
+				 "participants": [{"role": "REVIEWER", "state": "approved"}], "links": {"html": {"href": "https://bitbucket.org/workspace/repo/pull-requests/1"}}}
+			], "next": "%s/repositories/workspace/repo/pullrequests?page=2"}`, server.URL)
+			return
+		}
+		fmt.Fprint(w, `{"values": [
+			{"id": 2, "title": "second", "draft": true, "author": {"nickname": "john"}, "updated_on": "2022-10-03T08:12:34+00:00",
+			 "participants": [{"role": "REVIEWER", "state": "approved"}, {"role": "REVIEWER", "state": "changes_requested"}]}
+		]}`)
+	}))
+	defer server.Close()
+
+	provider := &BitbucketProvider{
+		Username: "john",
+		Token:    "secret",
+		Repos:    []string{"workspace/repo"},
+		cloudAPI: server.URL,
+	}
+
+	prs := fetchAll(t, provider)
+	if len(prs) != 2 {
+		t.Fatalf("expected 2 pull requests, got %d", len(prs))
+	}
+
+	first, second := prs[1], prs[2]
+	if first.Author != "jane" || first.Repository != "workspace/repo" || first.WebLink != "https://bitbucket.org/workspace/repo/pull-requests/1" {
+		t.Errorf("unexpected first pull request %+v", first)
+	}
+	if !first.Approved || first.RequiresChanges || first.Draft {
+		t.Errorf("expected first pull request to be approved, got %+v", first)
+	}
+	if second.Approved || !second.RequiresChanges || !second.Draft {
+		t.Errorf("expected second pull request to be a draft that requires changes, got %+v", second)
+	}
+}
+
+func TestBitbucketProvider_FetchServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/repo/pull-requests" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected bearer token, got '%s'", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("start") == "0" {
+			fmt.Fprint(w, `{"values": [
+				{"id": 1, "title": "first", "author": {"user": {"name": "jane"}}, "updatedDate": 1664784754000,
+				 "reviewers": [{"status": "NEEDS_WORK"}], "links": {"self": [{"href": "https://git.example.com/projects/PROJ/repos/repo/pull-requests/1"}]}}
+			], "isLastPage": false, "nextPageStart": 1}`)
+			return
+		}
+		fmt.Fprint(w, `{"values": [
+			{"id": 2, "title": "second", "author": {"user": {"name": "john"}}, "updatedDate": 1664784754000,
+			 "reviewers": [{"status": "APPROVED"}, {"status": "UNAPPROVED"}]}
+		], "isLastPage": true}`)
+	}))
+	defer server.Close()
+
+	provider := &BitbucketProvider{
+		URL:   server.URL,
+		Token: "secret",
+		Repos: []string{"PROJ/repo"},
+	}
+
+	prs := fetchAll(t, provider)
+	if len(prs) != 2 {
+		t.Fatalf("expected 2 pull requests, got %d", len(prs))
+	}
+
+	first, second := prs[1], prs[2]
+	if first.Author != "jane" || first.WebLink != "https://git.example.com/projects/PROJ/repos/repo/pull-requests/1" {
+		t.Errorf("unexpected first pull request %+v", first)
+	}
+	if first.Updated.Unix() != 1664784754 {
+		t.Errorf("expected first pull request to be updated at 1664784754, got %d", first.Updated.Unix())
+	}
+	if first.Approved || !first.RequiresChanges {
+		t.Errorf("expected first pull request to require changes, got %+v", first)
+	}
+	if !second.Approved || second.RequiresChanges {
+		t.Errorf("expected second pull request to be approved, got %+v", second)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// chatColorRepository, chatColorError and chatColorSummary are the colours of the sections
+	chatColorRepository = 0x439fe0
+	chatColorError      = 0xe01e5a
+	chatColorSummary    = 0x999999
+)
+
+// chatSection is a titled list of lines, e.g. the pull requests of a repository, that is sent as a
+// Discord embed or as a Mattermost or Rocket.Chat attachment
+type chatSection struct {
+	Title string
+	Lines []string
+	Color int
+}
+
+// Text returns the lines of the section
+func (s *chatSection) Text() string {
+	return strings.Join(s.Lines, "\n")
+}
+
+// HexColor returns the colour of the section as #rrggbb
+func (s *chatSection) HexColor() string {
+	return fmt.Sprintf("#%06x", s.Color)
+}
+
+func (s *chatSection) size() int {
+	return len(s.Title) + len(s.Text())
+}
+
+// chatSections renders the report as a section for each repository, one for what could not be
+// fetched and a summary, with the markup of a chat platform
+func chatSections(report *Report, m *markup) []*chatSection {
+	var sections []*chatSection
+	for _, repo := range report.Repositories {
+		section := &chatSection{Title: repo.Name, Color: chatColorRepository}
+		for _, pr := range repo.PullRequests {
+			section.Lines = append(section.Lines, "• "+m.pullRequest(pr))
+		}
+		sections = append(sections, section)
+	}
+
+	if len(report.Errors) > 0 {
+		section := &chatSection{Title: "Could not be fetched", Color: chatColorError}
+		for _, err := range report.Errors {
+			section.Lines = append(section.Lines, "• "+m.escape(err.Error()))
+		}
+		sections = append(sections, section)
+	}
+
+	return append(sections, &chatSection{Lines: m.summary(report), Color: chatColorSummary})
+}
+
+// splitChatSections splits the sections into messages with at most maxSections sections, or any
+// number if it's 0, and maxSize bytes of text. A section that is too large for a message on its own
+// is split into several sections, and the title of the following ones says that they are continued.
+func splitChatSections(sections []*chatSection, maxSections, maxSize int) [][]*chatSection {
+	var split []*chatSection
+	for _, section := range sections {
+		current := &chatSection{Title: section.Title, Color: section.Color}
+		for _, line := range section.Lines {
+			line = truncate(line, maxSize-len(section.Title)-len(" (continued)"))
+			if len(current.Lines) > 0 && current.size()+len(line)+1 > maxSize {
+				split = append(split, current)
+				current = &chatSection{Title: section.Title + " (continued)", Color: section.Color}
+			}
+			current.Lines = append(current.Lines, line)
+		}
+		split = append(split, current)
+	}
+
+	var messages [][]*chatSection
+	var message []*chatSection
+	size := 0
+	for _, section := range split {
+		if len(message) > 0 && (len(message) == maxSections || size+section.size() > maxSize) {
+			messages = append(messages, message)
+			message = nil
+			size = 0
+		}
+		message = append(message, section)
+		size += section.size()
+	}
+	return append(messages, message)
+}
@@ -5,22 +5,57 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 	//"github.com/mitchellh/mapstructure"
 )
 
 // Config contains the settings from the user
 type Config struct {
-	GitHubToken         string   `json:"github_token"`
-	GitHubOrganisations []string `json:"github_organisations"`
-	GitHubUsers         []string `json:"github_users"`
-	GitHubRepos         []string `json:"github_repos"`
-	GitLabToken         string   `json:"gitlab_token"`
-	GitLabRepos         []string `json:"gitlab_repos"`
-	GitlabURL           string   `json:"gitlab_url"`
-	SlackToken          string   `json:"slack_token"`
-	SlackChannel        string   `json:"slack_channel"`
-	Filters             *Filters `json:"filters"`
+	GitHubURL               string            `json:"github_url"`
+	GitHubToken             string            `json:"github_token"`
+	GitHubAppID             int64             `json:"github_app_id"`
+	GitHubAppInstallationID int64             `json:"github_app_installation_id"`
+	GitHubAppPrivateKeyFile string            `json:"github_app_private_key_file"`
+	GitHubOrganisations     []string          `json:"github_organisations"`
+	GitHubUsers             []string          `json:"github_users"`
+	GitHubRepos             []string          `json:"github_repos"`
+	GitHubQueries           []string          `json:"github_queries"`
+	GitHubExcludeArchived   bool              `json:"github_exclude_archived"`
+	GitHubExcludeForks      bool              `json:"github_exclude_forks"`
+	GitHubGraphQL           bool              `json:"github_graphql"`
+	GitHubConcurrency       int               `json:"github_concurrency"`
+	GitHubInstances         []*GitHubProvider `json:"github_instances"`
+	GitLabToken             string            `json:"gitlab_token"`
+	GitLabGroups            []string          `json:"gitlab_groups"`
+	GitLabIncludeSubgroups  bool              `json:"gitlab_include_subgroups"`
+	GitLabUsers             []string          `json:"gitlab_users"`
+	GitLabRepos             []string          `json:"gitlab_repos"`
+	GitlabURL               string            `json:"gitlab_url"`
+	BitbucketURL            string            `json:"bitbucket_url"`
+	BitbucketUsername       string            `json:"bitbucket_username"`
+	BitbucketToken          string            `json:"bitbucket_token"`
+	BitbucketRepos          []string          `json:"bitbucket_repos"`
+	GiteaURL                string            `json:"gitea_url"`
+	GiteaToken              string            `json:"gitea_token"`
+	GiteaOrganisations      []string          `json:"gitea_organisations"`
+	GiteaUsers              []string          `json:"gitea_users"`
+	GiteaRepos              []string          `json:"gitea_repos"`
+	AzureDevOpsURL          string            `json:"azure_devops_url"`
+	AzureDevOpsToken        string            `json:"azure_devops_token"`
+	AzureDevOpsOrganisation string            `json:"azure_devops_organisation"`
+	AzureDevOpsProjects     []string          `json:"azure_devops_projects"`
+	AzureDevOpsRepos        []string          `json:"azure_devops_repos"`
+	SlackToken              string            `json:"slack_token"`
+	SlackChannel            string            `json:"slack_channel"`
+	SlackWebhookURL         string            `json:"slack_webhook_url"`
+	SlackDirectMessages     bool              `json:"slack_direct_messages"`
+	SlackUsers              map[string]string `json:"slack_users"`
+	SlackUsersFile          string            `json:"slack_users_file"`
+	SlackMentions           string            `json:"slack_mentions"`
+	TeamsWebhookURL         string            `json:"teams_webhook_url"`
+	Outputs                 []*Output         `json:"outputs"`
+	Filters                 *Filters          `json:"filters"`
 }
 
 func newConfig(filePath string) (*Config, error) {
@@ -31,9 +66,10 @@ func newConfig(filePath string) (*Config, error) {
 
 	// the config Filters is an slice of interfaces, so we need to manually set defaults and add them to the Config
 	type filters struct {
-		Users  UserFilter   `json:"users"`
-		WIP    WIPFilter    `json:"wip"`
-		Review ReviewFilter `json:"review"`
+		Users     UserFilter     `json:"users"`
+		WIP       WIPFilter      `json:"wip"`
+		Review    ReviewFilter   `json:"review"`
+		Reviewers ReviewerFilter `json:"reviewers"`
 	}
 
 	filterConfig := struct {
@@ -62,9 +98,29 @@ func newConfig(filePath string) (*Config, error) {
 		}
 	}
 
+	if os.Getenv("GITHUB_URL") != "" {
+		config.GitHubURL = os.Getenv("GITHUB_URL")
+	}
 	if os.Getenv("GITHUB_TOKEN") != "" {
 		config.GitHubToken = os.Getenv("GITHUB_TOKEN")
 	}
+	if os.Getenv("GITHUB_APP_ID") != "" {
+		id, err := strconv.ParseInt(os.Getenv("GITHUB_APP_ID"), 10, 64)
+		if err != nil {
+			return config, fmt.Errorf("Error during config read: GITHUB_APP_ID: %s", err)
+		}
+		config.GitHubAppID = id
+	}
+	if os.Getenv("GITHUB_APP_INSTALLATION_ID") != "" {
+		id, err := strconv.ParseInt(os.Getenv("GITHUB_APP_INSTALLATION_ID"), 10, 64)
+		if err != nil {
+			return config, fmt.Errorf("Error during config read: GITHUB_APP_INSTALLATION_ID: %s", err)
+		}
+		config.GitHubAppInstallationID = id
+	}
+	if os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE") != "" {
+		config.GitHubAppPrivateKeyFile = os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE")
+	}
 	if os.Getenv("GITHUB_ORGANISATIONS") != "" {
 		config.GitHubOrganisations = strings.Split(os.Getenv("GITHUB_ORGANISATIONS"), ",")
 	}
@@ -74,21 +130,116 @@ func newConfig(filePath string) (*Config, error) {
 	if os.Getenv("GITHUB_REPOS") != "" {
 		config.GitHubRepos = strings.Split(os.Getenv("GITHUB_REPOS"), ",")
 	}
+	if os.Getenv("GITHUB_QUERIES") != "" {
+		config.GitHubQueries = strings.Split(os.Getenv("GITHUB_QUERIES"), ";")
+	}
+	if os.Getenv("GITHUB_EXCLUDE_ARCHIVED") != "" {
+		config.GitHubExcludeArchived = os.Getenv("GITHUB_EXCLUDE_ARCHIVED") == "true"
+	}
+	if os.Getenv("GITHUB_EXCLUDE_FORKS") != "" {
+		config.GitHubExcludeForks = os.Getenv("GITHUB_EXCLUDE_FORKS") == "true"
+	}
+	if os.Getenv("GITHUB_GRAPHQL") != "" {
+		config.GitHubGraphQL = os.Getenv("GITHUB_GRAPHQL") == "true"
+	}
+	if os.Getenv("GITHUB_CONCURRENCY") != "" {
+		concurrency, err := strconv.Atoi(os.Getenv("GITHUB_CONCURRENCY"))
+		if err != nil {
+			return config, fmt.Errorf("Error during config read: GITHUB_CONCURRENCY: %s", err)
+		}
+		config.GitHubConcurrency = concurrency
+	}
 	if os.Getenv("GITLAB_TOKEN") != "" {
 		config.GitLabToken = os.Getenv("GITLAB_TOKEN")
 	}
+	if os.Getenv("GITLAB_GROUPS") != "" {
+		config.GitLabGroups = strings.Split(os.Getenv("GITLAB_GROUPS"), ",")
+	}
+	if os.Getenv("GITLAB_INCLUDE_SUBGROUPS") != "" {
+		config.GitLabIncludeSubgroups = os.Getenv("GITLAB_INCLUDE_SUBGROUPS") == "true"
+	}
+	if os.Getenv("GITLAB_USERS") != "" {
+		config.GitLabUsers = strings.Split(os.Getenv("GITLAB_USERS"), ",")
+	}
 	if os.Getenv("GITLAB_REPOS") != "" {
 		config.GitLabRepos = strings.Split(os.Getenv("GITLAB_REPOS"), ",")
 	}
 	if os.Getenv("GITLAB_URL") != "" {
 		config.GitlabURL = os.Getenv("GITLAB_URL")
 	}
+	if os.Getenv("BITBUCKET_URL") != "" {
+		config.BitbucketURL = os.Getenv("BITBUCKET_URL")
+	}
+	if os.Getenv("BITBUCKET_USERNAME") != "" {
+		config.BitbucketUsername = os.Getenv("BITBUCKET_USERNAME")
+	}
+	if os.Getenv("BITBUCKET_TOKEN") != "" {
+		config.BitbucketToken = os.Getenv("BITBUCKET_TOKEN")
+	}
+	if os.Getenv("BITBUCKET_REPOS") != "" {
+		config.BitbucketRepos = strings.Split(os.Getenv("BITBUCKET_REPOS"), ",")
+	}
+	if os.Getenv("GITEA_URL") != "" {
+		config.GiteaURL = os.Getenv("GITEA_URL")
+	}
+	if os.Getenv("GITEA_TOKEN") != "" {
+		config.GiteaToken = os.Getenv("GITEA_TOKEN")
+	}
+	if os.Getenv("GITEA_ORGANISATIONS") != "" {
+		config.GiteaOrganisations = strings.Split(os.Getenv("GITEA_ORGANISATIONS"), ",")
+	}
+	if os.Getenv("GITEA_USERS") != "" {
+		config.GiteaUsers = strings.Split(os.Getenv("GITEA_USERS"), ",")
+	}
+	if os.Getenv("GITEA_REPOS") != "" {
+		config.GiteaRepos = strings.Split(os.Getenv("GITEA_REPOS"), ",")
+	}
+	if os.Getenv("AZURE_DEVOPS_URL") != "" {
+		config.AzureDevOpsURL = os.Getenv("AZURE_DEVOPS_URL")
+	}
+	if os.Getenv("AZURE_DEVOPS_TOKEN") != "" {
+		config.AzureDevOpsToken = os.Getenv("AZURE_DEVOPS_TOKEN")
+	}
+	if os.Getenv("AZURE_DEVOPS_ORGANISATION") != "" {
+		config.AzureDevOpsOrganisation = os.Getenv("AZURE_DEVOPS_ORGANISATION")
+	}
+	if os.Getenv("AZURE_DEVOPS_PROJECTS") != "" {
+		config.AzureDevOpsProjects = strings.Split(os.Getenv("AZURE_DEVOPS_PROJECTS"), ",")
+	}
+	if os.Getenv("AZURE_DEVOPS_REPOS") != "" {
+		config.AzureDevOpsRepos = strings.Split(os.Getenv("AZURE_DEVOPS_REPOS"), ",")
+	}
 	if os.Getenv("SLACK_TOKEN") != "" {
 		config.SlackToken = os.Getenv("SLACK_TOKEN")
 	}
 	if os.Getenv("SLACK_CHANNEL") != "" {
 		config.SlackChannel = os.Getenv("SLACK_CHANNEL")
 	}
+	if os.Getenv("SLACK_WEBHOOK_URL") != "" {
+		config.SlackWebhookURL = os.Getenv("SLACK_WEBHOOK_URL")
+	}
+	if os.Getenv("SLACK_DIRECT_MESSAGES") != "" {
+		config.SlackDirectMessages = os.Getenv("SLACK_DIRECT_MESSAGES") == "true"
+	}
+	if os.Getenv("SLACK_USERS") != "" {
+		config.SlackUsers = make(map[string]string)
+		for _, user := range strings.Split(os.Getenv("SLACK_USERS"), ",") {
+			parts := strings.SplitN(user, "=", 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+				return nil, fmt.Errorf("Error during config read: SLACK_USERS: %s is not a login=user pair", user)
+			}
+			config.SlackUsers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	if os.Getenv("SLACK_USERS_FILE") != "" {
+		config.SlackUsersFile = os.Getenv("SLACK_USERS_FILE")
+	}
+	if os.Getenv("SLACK_MENTIONS") != "" {
+		config.SlackMentions = os.Getenv("SLACK_MENTIONS")
+	}
+	if os.Getenv("TEAMS_WEBHOOK_URL") != "" {
+		config.TeamsWebhookURL = os.Getenv("TEAMS_WEBHOOK_URL")
+	}
 	if os.Getenv("FILTER_USERS") != "" {
 		filterConfig.Filters.Users = strings.Split(os.Getenv("FILTER_USERS"), ",")
 	}
@@ -98,13 +249,40 @@ func newConfig(filePath string) (*Config, error) {
 	if os.Getenv("FILTER_REVIEW") != "" {
 		filterConfig.Filters.Review = os.Getenv("FILTER_REVIEW") == "true"
 	}
+	if os.Getenv("FILTER_REVIEWERS") != "" {
+		filterConfig.Filters.Reviewers = strings.Split(os.Getenv("FILTER_REVIEWERS"), ",")
+	}
+
+	// the users file is a directory of logins to Slack users that can be shared between configs, the
+	// users in the config take precedence over it
+	if config.SlackUsersFile != "" {
+		file, err := ioutil.ReadFile(config.SlackUsersFile)
+		if err != nil {
+			return config, fmt.Errorf("Error during config read: %s", err)
+		}
+		users := make(map[string]string)
+		if err := json.Unmarshal(file, &users); err != nil {
+			return config, fmt.Errorf("Error during config read: %s: %s", config.SlackUsersFile, err)
+		}
+		for login, user := range config.SlackUsers {
+			users[login] = user
+		}
+		config.SlackUsers = users
+	}
 
 	config.Filters.Add(filterConfig.Filters.Users)
 	config.Filters.Add(filterConfig.Filters.Review)
 	config.Filters.Add(filterConfig.Filters.WIP)
+	config.Filters.Add(filterConfig.Filters.Reviewers)
 
 	config.GitHubRepos = deduplicate(config.GitHubRepos)
+	for _, instance := range config.GitHubInstances {
+		instance.Repos = deduplicate(instance.Repos)
+	}
 	config.GitLabRepos = deduplicate(config.GitLabRepos)
+	config.BitbucketRepos = deduplicate(config.BitbucketRepos)
+	config.GiteaRepos = deduplicate(config.GiteaRepos)
+	config.AzureDevOpsRepos = deduplicate(config.AzureDevOpsRepos)
 	return config, nil
 }
 
@@ -122,11 +300,13 @@ func deduplicate(s []string) []string {
 
 func (c *Config) validate() []error {
 	var errors []error
-	if c.SlackToken == "" {
-		errors = append(errors, fmt.Errorf("Slack token cannot be empty"))
+	// Slack is the default output when no other outputs have been configured
+	if len(c.Notifiers()) == 0 {
+		errors = append(errors, (&SlackNotifier{}).Validate()...)
 	}
-	if c.SlackChannel == "" {
-		errors = append(errors, fmt.Errorf("Slack channel cannot be empty"))
+	errors = append(errors, c.validateOutputs()...)
+	for _, provider := range c.Providers() {
+		errors = append(errors, provider.Validate()...)
 	}
 
 	return errors
@@ -138,16 +318,49 @@ func configHelp() {
 	fmt.Fprintln(os.Stderr, "\nThe configuration file (--config) looks like this:")
 
 	exampleConfig := &Config{
-		GitHubToken:         "secret_token",
-		GitHubOrganisations: []string{"facebook"},
-		GitHubUsers:         []string{"stojg"},
-		GitHubRepos:         []string{"user1/repo1", "user2/repo1"},
-		GitLabToken:         "secret_token",
-		GitLabRepos:         []string{"project1/repo1", "project2/repo1"},
-		GitlabURL:           "https://www.example.com",
-		SlackToken:          "secret_token",
-		SlackChannel:        "myteamchat",
-		Filters:             &Filters{},
+		GitHubToken:           "secret_token",
+		GitHubOrganisations:   []string{"facebook"},
+		GitHubUsers:           []string{"stojg"},
+		GitHubRepos:           []string{"user1/repo1", "user2/repo1"},
+		GitHubQueries:         []string{"is:pr is:open org:facebook review-requested:facebook/team"},
+		GitHubExcludeArchived: true,
+		GitHubExcludeForks:    true,
+		GitHubGraphQL:         false,
+		GitHubConcurrency:     10,
+		GitHubInstances: []*GitHubProvider{
+			{
+				URL:               "https://github.example.com",
+				AppID:             1234,
+				AppInstallationID: 5678,
+				AppPrivateKeyFile: "/etc/purr/github-app.private-key.pem",
+				Organisations:     []string{"engineering"},
+				Repos:             []string{"user1/repo1"},
+			},
+		},
+		GitLabToken:             "secret_token",
+		GitLabGroups:            []string{"group1"},
+		GitLabIncludeSubgroups:  true,
+		GitLabUsers:             []string{"user1"},
+		GitLabRepos:             []string{"project1/repo1", "project2/repo1"},
+		GitlabURL:               "https://www.example.com",
+		BitbucketUsername:       "username",
+		BitbucketToken:          "secret_app_password",
+		BitbucketRepos:          []string{"workspace1/repo1", "workspace2/repo1"},
+		GiteaURL:                "https://gitea.example.com",
+		GiteaToken:              "secret_token",
+		GiteaOrganisations:      []string{"gitea"},
+		GiteaUsers:              []string{"user1"},
+		GiteaRepos:              []string{"user1/repo1", "user2/repo1"},
+		AzureDevOpsToken:        "secret_token",
+		AzureDevOpsOrganisation: "organisation",
+		AzureDevOpsProjects:     []string{"project1"},
+		AzureDevOpsRepos:        []string{"project2/repo1"},
+		SlackToken:              "secret_token",
+		SlackChannel:            "myteamchat",
+		Outputs: []*Output{
+			{Type: "file", Path: "/var/lib/purr/report.json", Format: "json"},
+		},
+		Filters: &Filters{},
 	}
 
 	b, err := json.MarshalIndent(exampleConfig, "", "  ")
@@ -157,16 +370,49 @@ func configHelp() {
 	fmt.Fprintf(os.Stderr, "\n%s\n\n", b)
 
 	fmt.Fprint(os.Stderr, "The above configuration can be overridden with ENV variables:\n\n")
+	fmt.Fprintln(os.Stderr, " * GITHUB_URL - only for GitHub Enterprise Server")
 	fmt.Fprintln(os.Stderr, " * GITHUB_TOKEN")
+	fmt.Fprintln(os.Stderr, " * GITHUB_APP_ID")
+	fmt.Fprintln(os.Stderr, " * GITHUB_APP_INSTALLATION_ID")
+	fmt.Fprintln(os.Stderr, " * GITHUB_APP_PRIVATE_KEY_FILE")
 	fmt.Fprintln(os.Stderr, " * GITHUB_ORGANISATIONS - comma separated list")
 	fmt.Fprintln(os.Stderr, " * GITHUB_USERS - comma separated list")
 	fmt.Fprintln(os.Stderr, " * GITHUB_REPOS - comma separated list")
+	fmt.Fprintln(os.Stderr, " * GITHUB_QUERIES - semicolon separated list")
+	fmt.Fprintln(os.Stderr, " * GITHUB_EXCLUDE_ARCHIVED - 'true' or 'false'")
+	fmt.Fprintln(os.Stderr, " * GITHUB_EXCLUDE_FORKS - 'true' or 'false'")
+	fmt.Fprintln(os.Stderr, " * GITHUB_GRAPHQL - 'true' or 'false'")
+	fmt.Fprintln(os.Stderr, " * GITHUB_CONCURRENCY - maximum number of concurrent requests")
 	fmt.Fprintln(os.Stderr, " * GITLAB_TOKEN")
 	fmt.Fprintln(os.Stderr, " * GITLAB_URL")
+	fmt.Fprintln(os.Stderr, " * GITLAB_GROUPS - comma separated list")
+	fmt.Fprintln(os.Stderr, " * GITLAB_INCLUDE_SUBGROUPS - 'true' or 'false'")
+	fmt.Fprintln(os.Stderr, " * GITLAB_USERS - comma separated list")
 	fmt.Fprintln(os.Stderr, " * GITLAB_REPOS - comma separated list")
+	fmt.Fprintln(os.Stderr, " * BITBUCKET_URL - only for Bitbucket Server, leave empty for Bitbucket Cloud")
+	fmt.Fprintln(os.Stderr, " * BITBUCKET_USERNAME")
+	fmt.Fprintln(os.Stderr, " * BITBUCKET_TOKEN")
+	fmt.Fprintln(os.Stderr, " * BITBUCKET_REPOS - comma separated list")
+	fmt.Fprintln(os.Stderr, " * GITEA_URL")
+	fmt.Fprintln(os.Stderr, " * GITEA_TOKEN")
+	fmt.Fprintln(os.Stderr, " * GITEA_ORGANISATIONS - comma separated list")
+	fmt.Fprintln(os.Stderr, " * GITEA_USERS - comma separated list")
+	fmt.Fprintln(os.Stderr, " * GITEA_REPOS - comma separated list")
+	fmt.Fprintln(os.Stderr, " * AZURE_DEVOPS_URL - only for Azure DevOps Server, defaults to https://dev.azure.com")
+	fmt.Fprintln(os.Stderr, " * AZURE_DEVOPS_TOKEN")
+	fmt.Fprintln(os.Stderr, " * AZURE_DEVOPS_ORGANISATION")
+	fmt.Fprintln(os.Stderr, " * AZURE_DEVOPS_PROJECTS - comma separated list")
+	fmt.Fprintln(os.Stderr, " * AZURE_DEVOPS_REPOS - comma separated list")
 	fmt.Fprintln(os.Stderr, " * SLACK_TOKEN")
 	fmt.Fprintln(os.Stderr, " * SLACK_CHANNEL")
+	fmt.Fprintln(os.Stderr, " * SLACK_WEBHOOK_URL - instead of SLACK_TOKEN and SLACK_CHANNEL")
+	fmt.Fprintln(os.Stderr, " * SLACK_DIRECT_MESSAGES - 'true' or 'false'")
+	fmt.Fprintln(os.Stderr, " * SLACK_USERS - comma separated list of login=Slack user ID or email")
+	fmt.Fprintln(os.Stderr, " * SLACK_USERS_FILE - JSON file with the same mapping as SLACK_USERS")
+	fmt.Fprintln(os.Stderr, " * SLACK_MENTIONS - 'all' or 'reviewers'")
+	fmt.Fprintln(os.Stderr, " * TEAMS_WEBHOOK_URL")
 	fmt.Fprintln(os.Stderr, " * FILTER_USERS - comma separated list")
 	fmt.Fprintln(os.Stderr, " * FILTER_WIP - 'true' or 'false'")
 	fmt.Fprintln(os.Stderr, " * FILTER_REVIEW - 'true' or 'false'")
+	fmt.Fprintln(os.Stderr, " * FILTER_REVIEWERS - comma separated list of users and teams")
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -85,8 +86,8 @@ func TestNewConfig(t *testing.T) {
 		return
 	}
 
-	if len(config.Filters.filters) != 3 {
-		t.Errorf("expected 3 filters, got %d", len(config.Filters.filters))
+	if len(config.Filters.filters) != 4 {
+		t.Errorf("expected 4 filters, got %d", len(config.Filters.filters))
 		return
 	}
 
@@ -104,6 +105,10 @@ func TestNewConfig(t *testing.T) {
 			if !v {
 				t.Errorf("expected ReviewFilter to be enabled")
 			}
+		case ReviewerFilter:
+			if len(v) != 1 {
+				t.Errorf("expected 1 reviewer in ReviewerFilter, got %d", len(v))
+			}
 		default:
 			t.Errorf("unknown filter, %+v", v)
 		}
@@ -125,8 +130,8 @@ func TestNewConfig_NoFilters(t *testing.T) {
 		return
 	}
 
-	if len(config.Filters.filters) != 3 {
-		t.Errorf("Expected 3 filters, got '%d'", len(config.Filters.filters))
+	if len(config.Filters.filters) != 4 {
+		t.Errorf("Expected 4 filters, got '%d'", len(config.Filters.filters))
 		return
 	}
 }
@@ -146,8 +151,8 @@ func TestNewConfig_DisabledFilters(t *testing.T) {
 		return
 	}
 
-	if len(config.Filters.filters) != 3 {
-		t.Errorf("expected 3 filters, got %d", len(config.Filters.filters))
+	if len(config.Filters.filters) != 4 {
+		t.Errorf("expected 4 filters, got %d", len(config.Filters.filters))
 		return
 	}
 
@@ -165,6 +170,10 @@ func TestNewConfig_DisabledFilters(t *testing.T) {
 			if v {
 				t.Errorf("expected ReviewFilter to be disabled")
 			}
+		case ReviewerFilter:
+			if len(v) != 0 {
+				t.Errorf("expected 0 reviewers in ReviewerFilter, got %d", len(v))
+			}
 		default:
 			t.Errorf("unknown filter, %+v", v)
 		}
@@ -194,3 +203,244 @@ func TestNewConfig_Deduplication(t *testing.T) {
 		t.Errorf("Expected 2 gitlab repos, got '%d'", len(config.GitLabRepos))
 	}
 }
+
+func TestConfig_Providers(t *testing.T) {
+	config, err := newConfig("testdata/test_config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	providers := config.Providers()
+	if len(providers) != 2 {
+		t.Errorf("Expected 2 providers, got %d", len(providers))
+		return
+	}
+	if providers[0].Name() != "GitHub" {
+		t.Errorf("Expected first provider to be 'GitHub', got '%s'", providers[0].Name())
+	}
+	if providers[1].Name() != "GitLab" {
+		t.Errorf("Expected second provider to be 'GitLab', got '%s'", providers[1].Name())
+	}
+
+	config.GitLabRepos = nil
+	if len(config.Providers()) != 1 {
+		t.Errorf("Expected 1 provider when GitLab isn't configured, got %d", len(config.Providers()))
+	}
+}
+
+func TestConfig_ValidateProviders(t *testing.T) {
+	config, err := newConfig("testdata/test_config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	config.GitHubRepos = append(config.GitHubRepos, "not-a-repo")
+	config.GitlabURL = ""
+
+	validationErrors := config.validate()
+	if len(validationErrors) != 2 {
+		t.Errorf("Expected 2 validation errors, got %d: %v", len(validationErrors), validationErrors)
+	}
+}
+
+func TestConfig_ValidateSlackWebhook(t *testing.T) {
+	config, err := newConfig("testdata/test_config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	config.SlackToken = ""
+	config.SlackChannel = ""
+	config.SlackWebhookURL = "https://hooks.slack.com/services/T000/B000/XXXX"
+	if validationErrors := config.validate(); len(validationErrors) != 0 {
+		t.Errorf("Expected no validation errors, got %v", validationErrors)
+	}
+
+	for _, webhookURL := range []string{"/services/T000/B000/XXXX", "hooks.slack.com/services/T000/B000/XXXX", "ftp://hooks.slack.com/services", "https:///services"} {
+		config.SlackWebhookURL = webhookURL
+		if validationErrors := config.validate(); len(validationErrors) != 1 {
+			t.Errorf("Expected 1 validation error for %s, got %d: %v", webhookURL, len(validationErrors), validationErrors)
+		}
+	}
+
+	config.SlackToken = "secret_slack_token"
+	config.SlackWebhookURL = "not a url"
+	if validationErrors := config.validate(); len(validationErrors) != 2 {
+		t.Errorf("Expected 2 validation errors, got %d: %v", len(validationErrors), validationErrors)
+	}
+}
+
+func TestConfig_ValidateTeams(t *testing.T) {
+	config, err := newConfig("testdata/test_config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Slack is optional when the message is sent to Microsoft Teams
+	config.SlackToken = ""
+	config.SlackChannel = ""
+	config.TeamsWebhookURL = "https://example.webhook.office.com/webhookb2/xxxx"
+	if validationErrors := config.validate(); len(validationErrors) != 0 {
+		t.Errorf("Expected no validation errors, got %v", validationErrors)
+	}
+
+	// but it has to be complete if it's configured
+	config.SlackChannel = "myteamchat"
+	if validationErrors := config.validate(); len(validationErrors) != 1 {
+		t.Errorf("Expected 1 validation error, got %d: %v", len(validationErrors), validationErrors)
+	}
+}
+
+func TestNewConfig_GitHubInstances(t *testing.T) {
+	config, err := newConfig("testdata/test_config_github_instances.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	validationErrors := config.validate()
+	if len(validationErrors) != 0 {
+		for _, err := range validationErrors {
+			t.Errorf("Did not expect validation error: %+v", err)
+		}
+		return
+	}
+
+	providers := config.Providers()
+	if len(providers) != 2 {
+		t.Errorf("Expected 2 providers, got %d", len(providers))
+		return
+	}
+	if providers[0].Name() != "GitHub" {
+		t.Errorf("Expected first provider to be 'GitHub', got '%s'", providers[0].Name())
+	}
+	if providers[1].Name() != "GitHub (https://github.example.com)" {
+		t.Errorf("Expected second provider to be 'GitHub (https://github.example.com)', got '%s'", providers[1].Name())
+	}
+
+	instance, ok := providers[1].(*GitHubProvider)
+	if !ok {
+		t.Errorf("Expected second provider to be a *GitHubProvider, got %T", providers[1])
+		return
+	}
+	if instance.Token != "secret_enterprise_token" {
+		t.Errorf("Expected Token to be 'secret_enterprise_token', got '%s'", instance.Token)
+	}
+	if len(instance.Repos) != 1 {
+		t.Errorf("Expected 1 deduplicated repo, got %d", len(instance.Repos))
+	}
+}
+
+func TestConfig_ValidateGitHubInstances(t *testing.T) {
+	config, err := newConfig("testdata/test_config_github_instances.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	config.GitHubInstances = append(config.GitHubInstances,
+		&GitHubProvider{URL: "github.example.com", Repos: []string{"user1/repo1"}},
+		&GitHubProvider{URL: "ftp://github.example.com", Repos: []string{"user1/repo1"}},
+		&GitHubProvider{URL: "https://github.example.org"},
+	)
+	validationErrors := config.validate()
+	if len(validationErrors) != 3 {
+		t.Errorf("Expected 3 validation errors, got %d: %v", len(validationErrors), validationErrors)
+	}
+}
+
+func TestNewConfig_Outputs(t *testing.T) {
+	config, err := newConfig("testdata/test_config_outputs.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	validationErrors := config.validate()
+	if len(validationErrors) != 0 {
+		for _, err := range validationErrors {
+			t.Errorf("Did not expect validation error: %+v", err)
+		}
+		return
+	}
+
+	notifiers := config.Notifiers()
+	if len(notifiers) != 3 {
+		t.Errorf("Expected 3 notifiers, got %d", len(notifiers))
+		return
+	}
+	expected := []string{"Slack", "file (/tmp/purr.json)", "webhook (https://example.com/purr)"}
+	for i := range expected {
+		if notifiers[i].Name() != expected[i] {
+			t.Errorf("Expected notifier %d to be '%s', got '%s'", i, expected[i], notifiers[i].Name())
+		}
+	}
+
+	config.Outputs = append(config.Outputs, &Output{Type: "carrier-pigeon"}, &Output{Type: "file", Format: "pdf"})
+	if validationErrors := config.validate(); len(validationErrors) != 3 {
+		t.Errorf("Expected 3 validation errors, got %d: %v", len(validationErrors), validationErrors)
+	}
+}
+
+func TestNewConfig_SlackUsersFile(t *testing.T) {
+	config, err := newConfig("testdata/test_config_slack_users.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	validationErrors := config.validate()
+	if len(validationErrors) != 0 {
+		for _, err := range validationErrors {
+			t.Errorf("Did not expect validation error: %+v", err)
+		}
+		return
+	}
+
+	// the users in the config take precedence over the users file
+	expected := map[string]string{"jane": "U0000JANE", "joe": "U00000JOE"}
+	if len(config.SlackUsers) != len(expected) {
+		t.Errorf("Expected %d Slack users, got %v", len(expected), config.SlackUsers)
+	}
+	for login, user := range expected {
+		if config.SlackUsers[login] != user {
+			t.Errorf("Expected Slack user of %s to be '%s', got '%s'", login, user, config.SlackUsers[login])
+		}
+	}
+
+	notifier, ok := config.Notifiers()[0].(*SlackNotifier)
+	if !ok {
+		t.Fatalf("Expected the first notifier to be a *SlackNotifier, got %T", config.Notifiers()[0])
+	}
+	if notifier.Mentions != "reviewers" || len(notifier.Users) != 2 {
+		t.Errorf("Expected the Slack notifier to mention the reviewers of 2 users, got %+v", notifier)
+	}
+}
+
+func TestNewConfig_SlackUsersEnv(t *testing.T) {
+	t.Setenv("SLACK_USERS", " jane = U001, joe=joe@example.com")
+	config, err := newConfig("testdata/test_config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"jane": "U001", "joe": "joe@example.com"}
+	if len(config.SlackUsers) != len(expected) {
+		t.Errorf("Expected %d Slack users, got %v", len(expected), config.SlackUsers)
+	}
+	for login, user := range expected {
+		if config.SlackUsers[login] != user {
+			t.Errorf("Expected Slack user of %s to be '%s', got '%s'", login, user, config.SlackUsers[login])
+		}
+	}
+
+	for _, users := range []string{"jane", "jane=U001,=U002", "jane= "} {
+		t.Setenv("SLACK_USERS", users)
+		if _, err := newConfig("testdata/test_config.json"); err == nil || !strings.Contains(err.Error(), "SLACK_USERS") {
+			t.Errorf("Expected a SLACK_USERS error for '%s', got %v", users, err)
+		}
+	}
+}
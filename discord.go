@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+const (
+	// discordMaxEmbeds is the number of embeds Discord accepts per message
+	discordMaxEmbeds = 10
+	// discordMaxEmbedSize is the number of characters of the embeds per message, Discord accepts
+	// 4096 characters in a description and 6000 characters in all embeds of a message
+	discordMaxEmbedSize = 4000
+)
+
+func init() {
+	RegisterNotifier("discord", newDiscordNotifier)
+}
+
+// DiscordNotifier posts the report as embeds to a Discord webhook
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+func newDiscordNotifier(output *Output) Notifier {
+	return &DiscordNotifier{WebhookURL: output.WebhookURL}
+}
+
+// Name returns the name of the output
+func (n *DiscordNotifier) Name() string {
+	return "Discord"
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (n *DiscordNotifier) Validate() []error {
+	var errors []error
+	if _, err := url.ParseRequestURI(n.WebhookURL); err != nil {
+		errors = append(errors, fmt.Errorf("%s is not a valid Discord webhook URL: %v", n.WebhookURL, err))
+	}
+	return errors
+}
+
+// discordMessage is a message for a Discord webhook, see
+// https://discord.com/developers/docs/resources/webhook#execute-webhook
+type discordMessage struct {
+	Content         string                 `json:"content,omitempty"`
+	Embeds          []*discordEmbed        `json:"embeds"`
+	AllowedMentions discordAllowedMentions `json:"allowed_mentions"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+}
+
+// discordAllowedMentions selects which mentions in the message notify users
+type discordAllowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+// Notify posts the report to the Discord webhook, split into several messages if it has too many
+// repositories
+func (n *DiscordNotifier) Notify(ctx context.Context, report *Report) error {
+	for _, message := range discordMessages(report) {
+		if err := postJSON(ctx, defaultClient, n.WebhookURL, message, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discordMessages renders the report as messages with an embed for each repository
+func discordMessages(report *Report) []*discordMessage {
+	var messages []*discordMessage
+	for i, sections := range splitChatSections(chatSections(report, discordMarkup), discordMaxEmbeds, discordMaxEmbedSize) {
+		message := &discordMessage{
+			// an @everyone in a pull request title must not notify the whole server
+			AllowedMentions: discordAllowedMentions{Parse: []string{}},
+		}
+		if i == 0 {
+			message.Content = "**Open pull requests**"
+		}
+		for _, section := range sections {
+			message.Embeds = append(message.Embeds, &discordEmbed{Title: section.Title, Description: section.Text(), Color: section.Color})
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
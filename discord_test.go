@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiscordNotifier_Notify(t *testing.T) {
+	var messages []*discordMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message := &discordMessage{}
+		if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+			t.Error(err)
+		}
+		messages = append(messages, message)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	report := newSlackReport(2)
+	report.Repositories[0].PullRequests[0].Title = "@everyone fix *all* the_things"
+	report.Errors = []*FetchError{{Provider: "GitHub", Key: "acme/denied", Err: fmt.Errorf("403 Forbidden")}}
+	notifier := &DiscordNotifier{WebhookURL: server.URL}
+	if err := notifier.Notify(context.Background(), report); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	message := messages[0]
+	if message.AllowedMentions.Parse == nil || len(message.AllowedMentions.Parse) != 0 {
+		t.Errorf("Expected mentions to be disabled, got %v", message.AllowedMentions.Parse)
+	}
+	if len(message.Embeds) != 3 {
+		t.Fatalf("Expected an embed for the repository, the errors and the summary, got %d", len(message.Embeds))
+	}
+	if message.Embeds[0].Title != "acme/repo0" {
+		t.Errorf("Expected the first embed to be 'acme/repo0', got '%s'", message.Embeds[0].Title)
+	}
+	expected := `• [#1](https://github.com/acme/repo0/pull/1) @everyone fix \*all\* the\_things - _jane_ - updated`
+	if !strings.HasPrefix(message.Embeds[0].Description, expected) {
+		t.Errorf("Expected the description to start with\n%s\ngot\n%s", expected, message.Embeds[0].Description)
+	}
+	if message.Embeds[1].Color != chatColorError || !strings.Contains(message.Embeds[1].Description, "acme/denied") {
+		t.Errorf("Expected the second embed to list the errors, got %+v", message.Embeds[1])
+	}
+}
+
+func TestDiscordMessages_Split(t *testing.T) {
+	messages := discordMessages(newSlackReport(5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 100))
+	if len(messages) < 3 {
+		t.Fatalf("Expected the report to be split into several messages, got %d", len(messages))
+	}
+	for i, message := range messages {
+		if len(message.Embeds) > discordMaxEmbeds {
+			t.Errorf("Expected message %d to have at most %d embeds, got %d", i, discordMaxEmbeds, len(message.Embeds))
+		}
+		size := 0
+		for _, embed := range message.Embeds {
+			size += len(embed.Title) + len(embed.Description)
+		}
+		if size > 6000 {
+			t.Errorf("Expected message %d to have at most 6000 characters, got %d", i, size)
+		}
+	}
+	if messages[1].Content != "" {
+		t.Errorf("Expected only the first message to have a heading, got '%s'", messages[1].Content)
+	}
+	last := messages[len(messages)-1]
+	if last.Embeds[0].Title != "acme/repo11 (continued)" {
+		t.Errorf("Expected the last message to continue the repository, got '%s'", last.Embeds[0].Title)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// emailSubject is the subject template that is used when none has been configured
+const emailSubject = "{{.NumPullRequests}} open pull requests"
+
+func init() {
+	RegisterNotifier("email", newEmailNotifier)
+}
+
+// EmailNotifier sends the report as an email with a HTML and a plain text version via SMTP
+type EmailNotifier struct {
+	Host string
+	// Port defaults to 587 when StartTLS is enabled, otherwise to 25
+	Port     int
+	StartTLS bool
+	// Username and Password are used for PLAIN authentication when a username is set
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Subject is a text/template that is executed with the report
+	Subject string
+}
+
+func newEmailNotifier(output *Output) Notifier {
+	return &EmailNotifier{
+		Host:     output.Host,
+		Port:     output.Port,
+		StartTLS: output.StartTLS,
+		Username: output.Username,
+		Password: output.Password,
+		From:     output.From,
+		To:       output.To,
+		Subject:  output.Subject,
+	}
+}
+
+// Name returns the name of the output
+func (n *EmailNotifier) Name() string {
+	return fmt.Sprintf("email (%s)", strings.Join(n.To, ", "))
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (n *EmailNotifier) Validate() []error {
+	var errors []error
+	if n.Host == "" {
+		errors = append(errors, fmt.Errorf("Email host cannot be empty"))
+	}
+	if _, err := mail.ParseAddress(n.From); err != nil {
+		errors = append(errors, fmt.Errorf("%s is not a valid email from address: %v", n.From, err))
+	}
+	if len(n.To) == 0 {
+		errors = append(errors, fmt.Errorf("Email to addresses cannot be empty"))
+	}
+	for _, to := range n.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			errors = append(errors, fmt.Errorf("%s is not a valid email to address: %v", to, err))
+		}
+	}
+	if _, err := n.subjectTemplate(); err != nil {
+		errors = append(errors, fmt.Errorf("Email subject is not a valid template: %v", err))
+	}
+	return errors
+}
+
+func (n *EmailNotifier) subjectTemplate() (*template.Template, error) {
+	subject := n.Subject
+	if subject == "" {
+		subject = emailSubject
+	}
+	return template.New("subject").Parse(subject)
+}
+
+// Notify sends the report as an email to all recipients
+func (n *EmailNotifier) Notify(ctx context.Context, report *Report) error {
+	message, err := n.message(report, time.Now())
+	if err != nil {
+		return err
+	}
+
+	port := n.Port
+	if port == 0 {
+		port = 25
+		if n.StartTLS {
+			port = 587
+		}
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(n.Host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	// net/smtp doesn't support contexts, so the deadline of the context is used for the connection
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if n.StartTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: n.Host}); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(n.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range n.To {
+		to, _ := mail.ParseAddress(recipient)
+		if err := client.Rcpt(to.Address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message returns the email with a plain text and a HTML version of the report
+func (n *EmailNotifier) message(report *Report, now time.Time) ([]byte, error) {
+	subjectTemplate, err := n.subjectTemplate()
+	if err != nil {
+		return nil, err
+	}
+	subject := &strings.Builder{}
+	if err := subjectTemplate.Execute(subject, report); err != nil {
+		return nil, err
+	}
+
+	// the plain text part is shown as is, so it mustn't be escaped like Markdown
+	text := formatMarkdown(report, plainTextMarkup)
+	html, err := htmlFormatter{}.Format(report)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	body := multipart.NewWriter(buf)
+
+	fmt.Fprintf(buf, "From: %s\r\n", n.From)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject.String()))
+	fmt.Fprintf(buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprint(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", body.Boundary())
+
+	// email clients show the last part they support, so the HTML version goes last
+	for _, part := range []struct {
+		contentType string
+		data        []byte
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.data); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts a single email on a local port and sends the received commands and message
+// to the returned channels
+func fakeSMTPServer(t *testing.T) (string, int, <-chan []string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	commands := make(chan []string, 1)
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		var received []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			received = append(received, line)
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(line, "AUTH"):
+				reply("235 Authentication successful")
+			case strings.HasPrefix(line, "DATA"):
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				message := &strings.Builder{}
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					message.WriteString(line)
+				}
+				messages <- message.String()
+				reply("250 OK")
+			case strings.HasPrefix(line, "QUIT"):
+				reply("221 Bye")
+				commands <- received
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, commands, messages
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	host, port, commands, messages := fakeSMTPServer(t)
+
+	notifier := &EmailNotifier{
+		Host:     host,
+		Port:     port,
+		Username: "purr",
+		Password: "secret",
+		From:     "Purr <purr@example.com>",
+		To:       []string{"team@example.com", "Jane <jane@example.com>"},
+		Subject:  "{{.NumPullRequests}} PRs in {{len .Repositories}} repositories",
+	}
+	if errs := notifier.Validate(); len(errs) != 0 {
+		t.Fatalf("Did not expect validation errors, got %v", errs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	report := newSlackReport(2, 1)
+	report.Repositories[0].PullRequests[0].Title = "fix <script> in my_file *now*"
+	if err := notifier.Notify(ctx, report); err != nil {
+		t.Fatal(err)
+	}
+
+	received := strings.Join(<-commands, "\n")
+	for _, expected := range []string{"AUTH PLAIN", "MAIL FROM:<purr@example.com>", "RCPT TO:<team@example.com>", "RCPT TO:<jane@example.com>"} {
+		if !strings.Contains(received, expected) {
+			t.Errorf("Expected the SMTP commands to contain '%s', got\n%s", expected, received)
+		}
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(<-messages))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "3 PRs in 2 repositories" {
+		t.Errorf("Expected subject '3 PRs in 2 repositories', got '%s'", subject)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Expected a multipart/alternative email, got '%s'", mediaType)
+	}
+
+	parts := make(map[string]string)
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the reader decodes quoted-printable parts itself
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[part.Header.Get("Content-Type")] = string(body)
+	}
+
+	text := parts["text/plain; charset=utf-8"]
+	if !strings.Contains(text, "## acme/repo0") {
+		t.Errorf("Expected the plain text part to contain the repository, got\n%s", text)
+	}
+	if !strings.Contains(text, "fix <script> in my_file *now*") {
+		t.Errorf("Expected the plain text part to contain the unescaped title, got\n%s", text)
+	}
+	html := parts["text/html; charset=utf-8"]
+	if !strings.Contains(html, `<a href="https://github.com/acme/repo1/pull/1"`) || !strings.Contains(html, "fix &lt;script&gt;") {
+		t.Errorf("Expected the HTML part to contain the escaped pull requests, got\n%s", html)
+	}
+}
+
+func TestEmailNotifier_Validate(t *testing.T) {
+	notifier := &EmailNotifier{From: "not an address", To: []string{"team@example.com", "@"}, Subject: "{{.Missing"}
+	if errs := notifier.Validate(); len(errs) != 4 {
+		t.Errorf("Expected 4 validation errors, got %d: %v", len(errs), errs)
+	}
+
+	notifier = &EmailNotifier{Host: "smtp.example.com", From: "purr@example.com", To: []string{"team@example.com"}}
+	if errs := notifier.Validate(); len(errs) != 0 {
+		t.Errorf("Expected no validation errors, got %v", errs)
+	}
+	if notifier.Name() != "email (team@example.com)" {
+		t.Errorf("Expected name 'email (team@example.com)', got '%s'", notifier.Name())
+	}
+}
@@ -86,3 +86,37 @@ func (enabled ReviewFilter) Filter(p *PullRequest) bool {
 	return !p.RequiresChanges
 
 }
+
+// ReviewerFilter filters out any PR that isn't waiting on the review of one of the users or teams,
+// that is they have been requested to review it and haven't reviewed it yet
+type ReviewerFilter []string
+
+// Filter returns true if a PR should be kept and false if it should be discarded
+func (reviewers ReviewerFilter) Filter(p *PullRequest) bool {
+	if len(reviewers) == 0 {
+		return true
+	}
+
+	reviewed := make(map[string]bool)
+	for _, review := range p.Reviews {
+		if review.State != "" {
+			reviewed[strings.ToLower(review.Reviewer)] = true
+		}
+	}
+	requested := make(map[string]bool)
+	for _, reviewer := range p.Reviewers {
+		if !reviewed[strings.ToLower(reviewer)] {
+			requested[strings.ToLower(reviewer)] = true
+		}
+	}
+	for _, team := range p.ReviewerTeams {
+		requested[strings.ToLower(team)] = true
+	}
+
+	for _, reviewer := range reviewers {
+		if requested[strings.ToLower(reviewer)] {
+			return true
+		}
+	}
+	return false
+}
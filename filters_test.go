@@ -153,3 +153,47 @@ func TestReviewFilter_FilterDisabled(t *testing.T) {
 		}
 	}
 }
+
+func TestReviewerFilter_Filter(t *testing.T) {
+	tests := []struct {
+		pr        *PullRequest
+		reviewers []string
+		expected  bool
+	}{
+		{pr: &PullRequest{}, reviewers: []string{}, expected: true},
+		{pr: &PullRequest{}, reviewers: []string{"jane"}, expected: false},
+		{pr: &PullRequest{Author: "jane"}, reviewers: []string{"jane"}, expected: false},
+		{pr: &PullRequest{Reviewers: []string{"Jane"}}, reviewers: []string{"jane", "john"}, expected: true},
+		{pr: &PullRequest{Reviewers: []string{"joe"}}, reviewers: []string{"jane", "john"}, expected: false},
+		{pr: &PullRequest{ReviewerTeams: []string{"acme/backend"}}, reviewers: []string{"acme/backend"}, expected: true},
+		{
+			pr:        &PullRequest{Reviewers: []string{"jane"}, Reviews: []*Review{{Reviewer: "jane", State: ReviewApproved}}},
+			reviewers: []string{"jane"},
+			expected:  false,
+		},
+		{
+			pr:        &PullRequest{Reviewers: []string{"jane"}, Reviews: []*Review{{Reviewer: "jane", State: ReviewChangesRequested}}},
+			reviewers: []string{"jane"},
+			expected:  false,
+		},
+		{
+			pr:        &PullRequest{Reviewers: []string{"jane"}, Reviews: []*Review{{Reviewer: "jane", State: ReviewCommented}}},
+			reviewers: []string{"jane"},
+			expected:  false,
+		},
+		{
+			pr:        &PullRequest{Reviewers: []string{"jane"}, Reviews: []*Review{{Reviewer: "john", State: ReviewApproved}}},
+			reviewers: []string{"jane"},
+			expected:  true,
+		},
+	}
+
+	for i, test := range tests {
+		filters := &Filters{}
+		filters.Add(ReviewerFilter(test.reviewers))
+		actual := filters.Filter(test.pr)
+		if actual != test.expected {
+			t.Errorf("case %d. Expected '%t', got '%t', reviewers %s, pull request %+v", i+1, test.expected, actual, test.reviewers, test.pr)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Formatter renders a report for the outputs that don't have a format of their own, like files and
+// webhooks
+type Formatter interface {
+	Format(report *Report) ([]byte, error)
+	// ContentType returns the MIME type of the formatted report
+	ContentType() string
+}
+
+// formatters are the formatters that can be selected with the `format` of an output
+var formatters = map[string]Formatter{
+	"text":     textFormatter{},
+	"markdown": markdownFormatter{},
+	"json":     jsonFormatter{},
+	"html":     htmlFormatter{},
+}
+
+// newFormatter returns the formatter with the name, the text formatter is used when no name is given
+func newFormatter(name string) (Formatter, error) {
+	if name == "" {
+		name = "text"
+	}
+	formatter, ok := formatters[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a valid output format", name)
+	}
+	return formatter, nil
+}
+
+// textFormatter renders the report in the same format as the Slack message text
+type textFormatter struct{}
+
+func (textFormatter) Format(report *Report) ([]byte, error) {
+	return []byte(report.String()), nil
+}
+
+func (textFormatter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+// markdownFormatter renders the report as Markdown, e.g. for a file that is published as a build
+// artifact
+type markdownFormatter struct{}
+
+func (markdownFormatter) Format(report *Report) ([]byte, error) {
+	return formatMarkdown(report, markdownMarkup), nil
+}
+
+func (markdownFormatter) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+// formatMarkdown renders the report as a Markdown document with the links, emphasis and escaping of
+// the markup
+func formatMarkdown(report *Report, m *markup) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprint(buf, "# Open pull requests\n\n")
+	for _, repo := range report.Repositories {
+		fmt.Fprintf(buf, "## %s\n\n", m.escape(repo.Name))
+		for _, pr := range repo.PullRequests {
+			fmt.Fprintf(buf, "- %s\n", m.pullRequest(pr))
+		}
+		fmt.Fprint(buf, "\n")
+	}
+
+	if len(report.Errors) > 0 {
+		fmt.Fprint(buf, "## Could not be fetched\n\n")
+		for _, err := range report.Errors {
+			fmt.Fprintf(buf, "- %s\n", m.escape(err.Error()))
+		}
+		fmt.Fprint(buf, "\n")
+	}
+
+	if oldest := report.Oldest(); oldest != nil {
+		fmt.Fprintf(buf, "There are currently %d open pull requests and the oldest (%s) was updated %s\n\n",
+			report.NumPullRequests(), m.link(oldest.WebLink, fmt.Sprintf("PR #%d", oldest.ID)), humanize.Time(oldest.Updated))
+	}
+	fmt.Fprintf(buf, "%d pull request(s) filtered from these results\n", report.NumFiltered)
+	return buf.Bytes()
+}
+
+// jsonFormatter renders the report as JSON for other tools to consume
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(report *Report) ([]byte, error) {
+	result := struct {
+		Repositories []*RepositoryReport `json:"repositories"`
+		Errors       []string            `json:"errors"`
+		NumFiltered  int                 `json:"num_filtered"`
+	}{
+		Repositories: report.Repositories,
+		Errors:       []string{},
+		NumFiltered:  report.NumFiltered,
+	}
+	if result.Repositories == nil {
+		result.Repositories = []*RepositoryReport{}
+	}
+	for _, err := range report.Errors {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	return json.MarshalIndent(result, "", "  ")
+}
+
+func (jsonFormatter) ContentType() string {
+	return "application/json"
+}
+
+// htmlTemplate renders a report as a HTML document, with inline styles so that it can be used as
+// the body of an email
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"humanize": humanize.Time,
+}).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<h1>Open pull requests</h1>
+{{range .Repositories}}<h2>{{.Name}}</h2>
+<ul>
+{{range .PullRequests}}<li><a href="{{.WebLink}}">#{{.ID}}</a> {{.Title}} - <em>{{.Author}}</em>{{if .Approved}}, <strong>APPROVED</strong>{{end}}{{if .Assignee}}, assigned to <em>{{.Assignee}}</em>{{end}}{{with .WaitingOn}}, waiting on {{range $i, $reviewer := .}}{{if $i}}, {{end}}@{{$reviewer}}{{end}}{{end}} - updated {{humanize .Updated}}</li>
+{{end}}</ul>
+{{end}}{{if .Errors}}<h2>Could not be fetched</h2>
+<ul>
+{{range .Errors}}<li>{{.Error}}</li>
+{{end}}</ul>
+{{end}}<p style="color: #666666;">{{with .Oldest}}There are currently {{$.NumPullRequests}} open pull requests and the oldest (<a href="{{.WebLink}}">PR #{{.ID}}</a>) was updated {{humanize .Updated}}<br>
+{{end}}{{.NumFiltered}} pull request(s) filtered from these results</p>
+</body>
+</html>
+`))
+
+// htmlFormatter renders the report as a HTML document
+type htmlFormatter struct{}
+
+func (htmlFormatter) Format(report *Report) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := htmlTemplate.Execute(buf, report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (htmlFormatter) ContentType() string {
+	return "text/html; charset=utf-8"
+}
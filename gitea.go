@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// giteaPageSize is the number of items requested per page, Gitea caps it to 50 by default
+const giteaPageSize = 50
+
+func init() {
+	RegisterProvider(newGiteaProvider)
+}
+
+// GiteaProvider fetches pull requests from a Gitea or Forgejo server
+type GiteaProvider struct {
+	URL           string
+	Token         string
+	Organisations []string
+	Users         []string
+	Repos         []string
+}
+
+func newGiteaProvider(conf *Config) []Provider {
+	if len(conf.GiteaOrganisations) == 0 && len(conf.GiteaUsers) == 0 && len(conf.GiteaRepos) == 0 {
+		return nil
+	}
+	return []Provider{&GiteaProvider{
+		URL:           strings.TrimSuffix(conf.GiteaURL, "/"),
+		Token:         conf.GiteaToken,
+		Organisations: conf.GiteaOrganisations,
+		Users:         conf.GiteaUsers,
+		Repos:         conf.GiteaRepos,
+	}}
+}
+
+// Name returns the name of the provider
+func (p *GiteaProvider) Name() string {
+	return "Gitea"
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (p *GiteaProvider) Validate() []error {
+	var errors []error
+	if p.URL == "" {
+		errors = append(errors, fmt.Errorf("Gitea URL cannot be empty"))
+	}
+	for _, repoName := range p.Repos {
+		if len(strings.Split(repoName, "/")) != 2 {
+			errors = append(errors, fmt.Errorf("%s is not a valid Gitea repository", repoName))
+		}
+	}
+	return errors
+}
+
+type giteaRepository struct {
+	FullName string `json:"full_name"`
+}
+
+type giteaUser struct {
+	Login string `json:"login"`
+}
+
+type giteaPullRequest struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	HTMLURL   string     `json:"html_url"`
+	User      *giteaUser `json:"user"`
+	Assignee  *giteaUser `json:"assignee"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Draft     bool       `json:"draft"`
+}
+
+type giteaReview struct {
+	State     string `json:"state"`
+	Dismissed bool   `json:"dismissed"`
+}
+
+// Fetch returns a channel that emits all open pull requests from the configured repositories
+func (p *GiteaProvider) Fetch(ctx context.Context, log Logger, errs *FetchErrors) (<-chan *PullRequest, error) {
+	out := make(chan *PullRequest)
+
+	// create a sync group that is used to close the out channel when all gitea repos has been
+	// trawled
+	var wg sync.WaitGroup
+
+	client := defaultClient
+	if p.Token != "" {
+		client = newTokenClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: p.Token}))
+	}
+
+	var repos []string
+
+	for _, organisationName := range p.Organisations {
+		orgRepos, err := p.repositories(ctx, client, fmt.Sprintf("%s/api/v1/orgs/%s/repos", p.URL, organisationName))
+		if err != nil {
+			errs.Add(p.Name(), fmt.Sprintf("%s (organisation)", organisationName), err)
+			continue
+		}
+		repos = append(repos, orgRepos...)
+	}
+
+	for _, user := range p.Users {
+		userRepos, err := p.repositories(ctx, client, fmt.Sprintf("%s/api/v1/users/%s/repos", p.URL, user))
+		if err != nil {
+			errs.Add(p.Name(), fmt.Sprintf("%s (user)", user), err)
+			continue
+		}
+		repos = append(repos, userRepos...)
+	}
+
+	repos = append(repos, p.Repos...)
+
+	// spin out each request to find PRs on a repo into a separate goroutine so we fetch them
+	// asynchronous
+	for _, repo := range deduplicate(repos) {
+		wg.Add(1)
+
+		go func(repoName string) {
+			defer wg.Done()
+
+			log.Debugf("fetching Gitea PRs for %s\n", repoName)
+			for page := 1; ; page++ {
+				var pullRequests []*giteaPullRequest
+				endpoint := fmt.Sprintf("%s/api/v1/repos/%s/pulls?state=open&sort=recentupdate&page=%d&limit=%d", p.URL, repoName, page, giteaPageSize)
+				if err := getJSON(ctx, client, endpoint, &pullRequests); err != nil {
+					errs.Add(p.Name(), repoName, err)
+					return
+				}
+
+				// Gitea returns an empty page when there are no more results
+				if len(pullRequests) == 0 {
+					return
+				}
+
+				for _, pr := range pullRequests {
+					wg.Add(1)
+
+					// get the reviews and push result onto out when done
+					go func(pr *giteaPullRequest) {
+						defer wg.Done()
+
+						requiresChanges, approved := p.reviews(ctx, client, repoName, pr.Number, errs)
+
+						pullRequest := &PullRequest{
+							ID:              pr.Number,
+							Updated:         pr.UpdatedAt,
+							WebLink:         pr.HTMLURL,
+							Title:           pr.Title,
+							Repository:      repoName,
+							RequiresChanges: requiresChanges,
+							Approved:        approved,
+							Draft:           pr.Draft,
+						}
+						if pr.User != nil {
+							pullRequest.Author = pr.User.Login
+						}
+						if pr.Assignee != nil {
+							pullRequest.Assignee = pr.Assignee.Login
+						}
+						out <- pullRequest
+					}(pr)
+				}
+			}
+		}(repo)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+// repositories returns the full name of all repositories from a paginated repository list endpoint
+func (p *GiteaProvider) repositories(ctx context.Context, client *http.Client, endpoint string) ([]string, error) {
+	var repos []string
+	for page := 1; ; page++ {
+		var result []*giteaRepository
+		if err := getJSON(ctx, client, fmt.Sprintf("%s?page=%d&limit=%d", endpoint, page, giteaPageSize), &result); err != nil {
+			return repos, err
+		}
+		if len(result) == 0 {
+			return repos, nil
+		}
+		for _, repo := range result {
+			repos = append(repos, repo.FullName)
+		}
+	}
+}
+
+// reviews goes through the reviews of a single PR and returns a few flags: requiresChanges, approved
+func (p *GiteaProvider) reviews(ctx context.Context, client *http.Client, repoName string, number int, errs *FetchErrors) (bool, bool) {
+	requiresChanges := false
+	approved := false
+
+	for page := 1; ; page++ {
+		var reviews []*giteaReview
+		endpoint := fmt.Sprintf("%s/api/v1/repos/%s/pulls/%d/reviews?page=%d&limit=%d", p.URL, repoName, number, page, giteaPageSize)
+		if err := getJSON(ctx, client, endpoint, &reviews); err != nil {
+			errs.Add(p.Name(), fmt.Sprintf("%s#%d", repoName, number), err)
+			return false, false
+		}
+		if len(reviews) == 0 {
+			break
+		}
+
+		// the list of reviews is in chronological order, which means that if a review requires changes
+		// after it's been approved, the PRs approval state is false
+		for _, review := range reviews {
+			if review.Dismissed {
+				continue
+			}
+			if review.State == "REQUEST_CHANGES" {
+				requiresChanges = true
+				approved = false
+			}
+			if review.State == "APPROVED" {
+				approved = true
+				requiresChanges = false
+			}
+		}
+	}
+
+	return requiresChanges, approved
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGiteaProvider_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected bearer token, got '%s'", r.Header.Get("Authorization"))
+		}
+		page := r.URL.Query().Get("page")
+		switch r.URL.Path {
+		case "/api/v1/orgs/acme/repos":
+			if page == "1" {
+				fmt.Fprint(w, `[{"full_name": "acme/one"}, {"full_name": "acme/two"}]`)
+				return
+			}
+			fmt.Fprint(w, `[]`)
+		case "/api/v1/repos/acme/one/pulls":
+			if page == "1" {
+				fmt.Fprint(w, `[{"number": 1, "title": "first", "html_url": "https://gitea.local/acme/one/pulls/1",
+					"user": {"login": "jane"}, "assignee": {"login": "john"}, "updated_at": "2022-10-03T08:12:34Z"}]`)
+				return
+			}
+			fmt.Fprint(w, `[]`)
+		case "/api/v1/repos/acme/two/pulls":
+			if page == "1" {
+				fmt.Fprint(w, `[{"number": 2, "title": "second", "user": {"login": "john"}, "updated_at": "2022-10-03T08:12:34Z"}]`)
+				return
+			}
+			fmt.Fprint(w, `[]`)
+		case "/api/v1/repos/acme/one/pulls/1/reviews":
+			if page == "1" {
+				fmt.Fprint(w, `[{"state": "REQUEST_CHANGES"}, {"state": "APPROVED"}]`)
+				return
+			}
+			fmt.Fprint(w, `[]`)
+		case "/api/v1/repos/acme/two/pulls/2/reviews":
+			if page == "1" {
+				fmt.Fprint(w, `[{"state": "APPROVED"}, {"state": "REQUEST_CHANGES"}, {"state": "APPROVED", "dismissed": true}]`)
+				return
+			}
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider := &GiteaProvider{
+		URL:           server.URL,
+		Token:         "secret",
+		Organisations: []string{"acme"},
+		Repos:         []string{"acme/one"},
+	}
+
+	prs := fetchAll(t, provider)
+	if len(prs) != 2 {
+		t.Fatalf("expected 2 pull requests, got %d", len(prs))
+	}
+
+	first, second := prs[1], prs[2]
+	if first.Author != "jane" || first.Assignee != "john" || first.Repository != "acme/one" {
+		t.Errorf("unexpected first pull request %+v", first)
+	}
+	if !first.Approved || first.RequiresChanges {
+		t.Errorf("expected first pull request to be approved, got %+v", first)
+	}
+	if second.Approved || !second.RequiresChanges {
+		t.Errorf("expected second pull request to require changes, got %+v", second)
+	}
+}
@@ -3,72 +3,241 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
-	"sync"
 
 	"github.com/google/go-github/v47/github"
 	"golang.org/x/oauth2"
 )
 
-func trawlGitHub(conf *Config, log Logger) <-chan *PullRequest {
+func init() {
+	RegisterProvider(newGitHubProviders)
+}
 
-	out := make(chan *PullRequest)
+// GitHubProvider fetches pull requests from GitHub organisations, users and repositories. It's
+// also the configuration for each of the GitHub instances in `github_instances`.
+type GitHubProvider struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+	// AppID, AppInstallationID and AppPrivateKeyFile authenticates as a GitHub App installation
+	// instead of with a token
+	AppID             int64    `json:"app_id"`
+	AppInstallationID int64    `json:"app_installation_id"`
+	AppPrivateKeyFile string   `json:"app_private_key_file"`
+	Organisations     []string `json:"organisations"`
+	Users             []string `json:"users"`
+	Repos             []string `json:"repos"`
+	Queries           []string `json:"queries"`
+	ExcludeArchived   bool     `json:"exclude_archived"`
+	ExcludeForks      bool     `json:"exclude_forks"`
+	GraphQL           bool     `json:"graphql"`
+	// Concurrency is the maximum number of concurrent REST API requests
+	Concurrency int `json:"concurrency"`
+}
 
-	// create a sync group that is used to close the out channel when all github repos has been
-	// trawled
-	var wg sync.WaitGroup
+// newGitHubProviders returns a provider for the top level GitHub configuration and one for each
+// of the configured GitHub instances
+func newGitHubProviders(conf *Config) []Provider {
+	var providers []Provider
+	if len(conf.GitHubOrganisations) > 0 || len(conf.GitHubUsers) > 0 || len(conf.GitHubRepos) > 0 || len(conf.GitHubQueries) > 0 {
+		providers = append(providers, &GitHubProvider{
+			URL:               conf.GitHubURL,
+			Token:             conf.GitHubToken,
+			AppID:             conf.GitHubAppID,
+			AppInstallationID: conf.GitHubAppInstallationID,
+			AppPrivateKeyFile: conf.GitHubAppPrivateKeyFile,
+			Organisations:     conf.GitHubOrganisations,
+			Users:             conf.GitHubUsers,
+			Repos:             conf.GitHubRepos,
+			Queries:           conf.GitHubQueries,
+			ExcludeArchived:   conf.GitHubExcludeArchived,
+			ExcludeForks:      conf.GitHubExcludeForks,
+			GraphQL:           conf.GitHubGraphQL,
+			Concurrency:       conf.GitHubConcurrency,
+		})
+	}
+	for _, instance := range conf.GitHubInstances {
+		providers = append(providers, instance)
+	}
+	return providers
+}
 
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: conf.GitHubToken})
-	tc := oauth2.NewClient(context.Background(), ts)
-	client := github.NewClient(tc)
+// Name returns the name of the provider
+func (p *GitHubProvider) Name() string {
+	if p.URL == "" {
+		return "GitHub"
+	}
+	return fmt.Sprintf("GitHub (%s)", p.URL)
+}
 
-	var repos []string
+// Validate returns a list of errors for any invalid configuration
+func (p *GitHubProvider) Validate() []error {
+	var errors []error
+	// an empty URL means github.com, anything else has to be the absolute URL of a GitHub
+	// Enterprise Server
+	if p.URL != "" {
+		if u, err := url.ParseRequestURI(p.URL); err != nil {
+			errors = append(errors, fmt.Errorf("%s is not a valid GitHub URL: %v", p.URL, err))
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			errors = append(errors, fmt.Errorf("%s is not a valid GitHub URL: scheme must be http or https", p.URL))
+		}
+	}
+	if len(p.Organisations) == 0 && len(p.Users) == 0 && len(p.Repos) == 0 && len(p.Queries) == 0 {
+		errors = append(errors, fmt.Errorf("%s needs at least one organisation, user, repository or query", p.Name()))
+	}
+	if p.AppID != 0 {
+		if p.Token != "" {
+			errors = append(errors, fmt.Errorf("GitHub token and GitHub App cannot both be configured"))
+		}
+		if p.AppInstallationID == 0 {
+			errors = append(errors, fmt.Errorf("GitHub App installation ID cannot be empty"))
+		}
+		if p.AppPrivateKeyFile == "" {
+			errors = append(errors, fmt.Errorf("GitHub App private key file cannot be empty"))
+		}
+	}
+	if p.Concurrency < 0 {
+		errors = append(errors, fmt.Errorf("GitHub concurrency cannot be negative"))
+	}
+	for _, repoName := range p.Repos {
+		if len(strings.Split(repoName, "/")) != 2 {
+			errors = append(errors, fmt.Errorf("%s is not a valid GitHub repository", repoName))
+		}
+	}
+	return errors
+}
 
-	// check for a organisation and all it's repositories
-	for _, organisationName := range conf.GitHubOrganisations {
-		// first try listing by organisation
-		allRepos, _, err := client.Repositories.ListByOrg(context.Background(), organisationName, nil)
-		if err != nil {
-			log.Infof("Failed getting repositories for GitHub organisation %s: %v\n", organisationName, err)
-			continue
+// Fetch returns a channel that emits all open pull requests from the configured repositories
+func (p *GitHubProvider) Fetch(ctx context.Context, log Logger, errs *FetchErrors) (<-chan *PullRequest, error) {
+	rateLimits := &gitHubRateLimitTransport{log: log}
+	tc, client, err := p.client(ctx, rateLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := p.repositories(ctx, client, log, errs)
+
+	// all REST API requests share the same pool, so that the number of concurrent requests stays
+	// within the secondary rate limits
+	concurrency := p.Concurrency
+	if concurrency == 0 {
+		concurrency = gitHubConcurrency
+	}
+	pool := newWorkerPool(concurrency)
+
+	var prs <-chan *PullRequest
+	if p.GraphQL {
+		prs = p.fetchGraphQL(ctx, tc, gitHubGraphQLURL(client.BaseURL), repos, log, errs)
+	} else {
+		prs = p.fetchREST(ctx, client, pool, repos, log, errs)
+	}
+
+	if len(p.Queries) > 0 {
+		// a pull request can be found both in a repository and by one or more search queries
+		prs = uniquePullRequests(merge(ctx, prs, p.fetchSearch(ctx, client, pool, log, errs)))
+	}
+
+	out := make(chan *PullRequest)
+	go func() {
+		for pr := range prs {
+			out <- pr
 		}
-		for i := range allRepos {
-			repos = append(repos, *allRepos[i].FullName)
+		rateLimits.report(p.Name())
+		close(out)
+	}()
+	return out, nil
+}
+
+// client returns an authenticated HTTP client and a GitHub client that uses it, all requests are
+// sent through the rate limit transport
+func (p *GitHubProvider) client(ctx context.Context, rateLimits *gitHubRateLimitTransport) (*http.Client, *github.Client, error) {
+	newClient := func(tc *http.Client) (*github.Client, error) {
+		// GitHub Enterprise Server has the API and uploads on the same host as the web interface
+		if p.URL != "" {
+			return github.NewEnterpriseClient(p.URL, p.URL, tc)
 		}
+		return github.NewClient(tc), nil
 	}
 
-	for _, user := range conf.GitHubUsers {
-		// first try listing by organisation
-		allRepos, _, err := client.Repositories.List(context.Background(), user, nil)
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: p.Token})
+	if p.AppID != 0 {
+		// an unauthenticated client is used to find the API URL that the app tokens are minted from
+		unauthenticated, err := newClient(nil)
 		if err != nil {
-			log.Infof("Failed getting repositories for GitHub user %s: %v\n", user, err)
-			continue
+			return nil, nil, err
 		}
-		for i := range allRepos {
-			repos = append(repos, *allRepos[i].FullName)
+		ts, err = newGitHubAppTokenSource(ctx, unauthenticated.BaseURL, p.AppID, p.AppInstallationID, p.AppPrivateKeyFile)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
-	for _, repoName := range conf.GitHubRepos {
-		repoParts := strings.Split(repoName, "/")
-		if len(repoParts) != 2 {
-			log.Infof("%s is not a valid GitHub repository\n", repoName)
-			continue
+	rateLimits.Base = &oauth2.Transport{Source: oauth2.ReuseTokenSource(nil, ts), Base: defaultTransport}
+	tc := &http.Client{Transport: rateLimits}
+	client, err := newClient(tc)
+	return tc, client, err
+}
+
+// repositories returns the full names of all configured repositories and the repositories of the
+// configured organisations and users
+func (p *GitHubProvider) repositories(ctx context.Context, client *github.Client, log Logger, errs *FetchErrors) []string {
+	var repos []string
+
+	// check for a organisation and all it's repositories
+	for _, organisationName := range p.Organisations {
+		options := &github.RepositoryListByOrgOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			allRepos, resp, err := client.Repositories.ListByOrg(ctx, organisationName, options)
+			if err != nil {
+				errs.Add(p.Name(), fmt.Sprintf("%s (organisation)", organisationName), err)
+				break
+			}
+			repos = append(repos, p.repositoryNames(allRepos, log)...)
+
+			// the GitHub API returns 0 as the NextPage if there are no more pages of result
+			if resp.NextPage == 0 {
+				break
+			}
+			options.Page = resp.NextPage
 		}
-		repos = append(repos, repoName)
 	}
 
-	// spin out each request to find PRs on a repo into a separate goroutine so we fetch them
-	// asynchronous
-	for _, repo := range repos {
+	for _, user := range p.Users {
+		options := &github.RepositoryListOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			allRepos, resp, err := client.Repositories.List(ctx, user, options)
+			if err != nil {
+				errs.Add(p.Name(), fmt.Sprintf("%s (user)", user), err)
+				break
+			}
+			repos = append(repos, p.repositoryNames(allRepos, log)...)
+
+			if resp.NextPage == 0 {
+				break
+			}
+			options.Page = resp.NextPage
+		}
+	}
 
-		// increment the wait group
-		wg.Add(1)
+	repos = append(repos, p.Repos...)
+	return deduplicate(repos)
+}
 
-		go func(repoName string) {
-			// when finished, decrement the wait group
-			defer wg.Done()
+// fetchREST returns a channel that emits the open pull requests of the repositories, using one
+// REST API request per page of pull requests and one per page of reviews
+func (p *GitHubProvider) fetchREST(ctx context.Context, client *github.Client, pool *workerPool, repos []string, log Logger, errs *FetchErrors) <-chan *PullRequest {
+	out := make(chan *PullRequest)
 
+	// spin out each request to find PRs on a repo into the worker pool so we fetch them
+	// asynchronous
+	for _, repo := range repos {
+		repoName := repo
+		pool.Go(func() {
 			parts := strings.Split(repoName, "/")
 
 			// nextPage keeps track of of the current page of the paginated response from the
@@ -87,38 +256,20 @@ func trawlGitHub(conf *Config, log Logger) <-chan *PullRequest {
 
 				// get the pull requests
 				log.Debugf("fetching all PRs for GitHub repo %s\n", repoName)
-				pullRequests, resp, err := client.PullRequests.List(context.Background(), parts[0], parts[1], options)
+				pullRequests, resp, err := client.PullRequests.List(ctx, parts[0], parts[1], options)
 				if err != nil {
-					log.Infof("couldn't fetch PRs from GitHub (%s): %s\n", repoName, err)
+					errs.Add(p.Name(), repoName, err)
 					return
 				}
 
-				// transform the GitHub pull request struct into a provider agnostic struct
 				for _, pr := range pullRequests {
-					wg.Add(1)
-
-					// Get the github reviews and push result onto out when done
-					go func(pr *github.PullRequest) {
-						defer wg.Done()
-
-						requiresChanges, approved := trawlGitHubReviews(client, parts[0], parts[1], *pr.Number, log)
-
-						pullRequest := &PullRequest{
-							ID:              *pr.Number,
-							Author:          *pr.User.Login,
-							Updated:         *pr.UpdatedAt,
-							WebLink:         *pr.HTMLURL,
-							Title:           *pr.Title,
-							RequiresChanges: requiresChanges,
-							Approved:        approved,
-							Repository:      fmt.Sprintf("%s/%s", parts[0], parts[1]),
-							Draft:           *pr.Draft,
-						}
-						if pr.Assignee != nil {
-							pullRequest.Assignee = *pr.Assignee.Login
-						}
-						out <- pullRequest
-					}(pr)
+					pr := pr
+
+					// transform the GitHub pull request struct into a provider agnostic struct and push
+					// result onto out when done
+					pool.Go(func() {
+						out <- p.fromGitHubPullRequest(ctx, client, parts[0], parts[1], pr, errs)
+					})
 				}
 
 				// the GitHub API returns 0 as the LastPage if there are no more pages of result
@@ -128,22 +279,78 @@ func trawlGitHub(conf *Config, log Logger) <-chan *PullRequest {
 				nextPage++
 
 			}
-		}(repo)
+		})
 	}
 
 	// Spin off a go routine that will close the channel when all repos have finished
 	go func() {
-		wg.Wait()
+		pool.Wait()
 		close(out)
 	}()
 
 	return out
 }
 
-// trawlGitHubReviews goes through the reviews of a single PR and returns a few flags: requiresChanges, approved
-func trawlGitHubReviews(client *github.Client, owner string, repo string, number int, log Logger) (bool, bool) {
+// fromGitHubPullRequest gets the reviews of a GitHub pull request and transforms it into a provider
+// agnostic struct
+func (p *GitHubProvider) fromGitHubPullRequest(ctx context.Context, client *github.Client, owner string, repo string, pr *github.PullRequest, errs *FetchErrors) *PullRequest {
+	requiresChanges, approved, reviews, err := trawlGitHubReviews(ctx, client, owner, repo, *pr.Number)
+	if err != nil {
+		errs.Add(p.Name(), fmt.Sprintf("%s/%s#%d", owner, repo, *pr.Number), err)
+	}
+
+	pullRequest := &PullRequest{
+		ID:              *pr.Number,
+		Author:          *pr.User.Login,
+		Updated:         *pr.UpdatedAt,
+		WebLink:         *pr.HTMLURL,
+		Title:           *pr.Title,
+		RequiresChanges: requiresChanges,
+		Approved:        approved,
+		Repository:      fmt.Sprintf("%s/%s", owner, repo),
+		Draft:           *pr.Draft,
+		Reviews:         reviews,
+		Approvals:       countApprovals(reviews),
+	}
+	if pr.Assignee != nil {
+		pullRequest.Assignee = *pr.Assignee.Login
+	}
+	for _, reviewer := range pr.RequestedReviewers {
+		pullRequest.Reviewers = append(pullRequest.Reviewers, reviewer.GetLogin())
+	}
+	for _, team := range pr.RequestedTeams {
+		pullRequest.ReviewerTeams = append(pullRequest.ReviewerTeams, fmt.Sprintf("%s/%s", owner, team.GetSlug()))
+	}
+	for _, label := range pr.Labels {
+		pullRequest.Labels = append(pullRequest.Labels, label.GetName())
+	}
+	return pullRequest
+}
+
+// repositoryNames returns the full names of the repositories, except the archived and forked
+// repositories if they are excluded
+func (p *GitHubProvider) repositoryNames(repos []*github.Repository, log Logger) []string {
+	var names []string
+	for _, repo := range repos {
+		if p.ExcludeArchived && repo.GetArchived() {
+			log.Debugf("skipping archived GitHub repo %s\n", repo.GetFullName())
+			continue
+		}
+		if p.ExcludeForks && repo.GetFork() {
+			log.Debugf("skipping forked GitHub repo %s\n", repo.GetFullName())
+			continue
+		}
+		names = append(names, repo.GetFullName())
+	}
+	return names
+}
+
+// trawlGitHubReviews goes through the reviews of a single PR and returns a few flags: requiresChanges,
+// approved, and the latest review of each reviewer
+func trawlGitHubReviews(ctx context.Context, client *github.Client, owner string, repo string, number int) (bool, bool, []*Review, error) {
 	requiresChanges := false
 	approved := false
+	reviews := newLatestReviews()
 
 	nextPage := 1
 	for {
@@ -152,15 +359,15 @@ func trawlGitHubReviews(client *github.Client, owner string, repo string, number
 		}
 
 		// get the reviews for the PR
-		pullRequestReviews, resp, err := client.PullRequests.ListReviews(context.Background(), owner, repo, number, options)
+		pullRequestReviews, resp, err := client.PullRequests.ListReviews(ctx, owner, repo, number, options)
 		if err != nil {
-			log.Infof("Couldn't fetch PR reviews from GitHub (%s/%s#%d): %s\n", owner, repo, number, err)
-			return false, false
+			return false, false, nil, err
 		}
 
 		// the list of reviews is in chronological order, which means that if a review requires changes
 		// after it's been approved, the PRs approval state is false
 		for _, review := range pullRequestReviews {
+			reviews.add(review.GetUser().GetLogin(), review.GetState())
 			if *review.State == "CHANGES_REQUESTED" {
 				requiresChanges = true
 				approved = false
@@ -178,5 +385,63 @@ func trawlGitHubReviews(client *github.Client, owner string, repo string, number
 		nextPage++
 	}
 
-	return requiresChanges, approved
+	return requiresChanges, approved, reviews.list(), nil
+}
+
+// latestReviews keeps the latest review of each reviewer from GitHub reviews in chronological order
+type latestReviews struct {
+	reviews []*Review
+	byUser  map[string]*Review
+}
+
+func newLatestReviews() *latestReviews {
+	return &latestReviews{byUser: make(map[string]*Review)}
+}
+
+// add adds a review with a GitHub review state. Like on GitHub, a comment doesn't replace an approval
+// or change request and a dismissed review doesn't count any more.
+func (l *latestReviews) add(user, state string) {
+	var normalised string
+	switch state {
+	case "APPROVED":
+		normalised = ReviewApproved
+	case "CHANGES_REQUESTED":
+		normalised = ReviewChangesRequested
+	case "COMMENTED":
+		normalised = ReviewCommented
+	case "DISMISSED":
+		if review, ok := l.byUser[user]; ok {
+			review.State = ReviewCommented
+		}
+		return
+	default:
+		// pending reviews haven't been submitted yet
+		return
+	}
+
+	review, ok := l.byUser[user]
+	if !ok {
+		review = &Review{Reviewer: user}
+		l.byUser[user] = review
+		l.reviews = append(l.reviews, review)
+	}
+	if normalised != ReviewCommented || review.State == "" {
+		review.State = normalised
+	}
+}
+
+// list returns the reviews in the order the reviewers first reviewed
+func (l *latestReviews) list() []*Review {
+	return l.reviews
+}
+
+// countApprovals returns the number of reviews that approve
+func countApprovals(reviews []*Review) int {
+	approvals := 0
+	for _, review := range reviews {
+		if review.State == ReviewApproved {
+			approvals++
+		}
+	}
+	return approvals
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// gitHubAppTokenSource mints installation access tokens for a GitHub App. Installation tokens
+// expire after an hour, so it should be wrapped in an oauth2.ReuseTokenSource that asks for a new
+// token when the current one has expired.
+type gitHubAppTokenSource struct {
+	ctx            context.Context
+	baseURL        *url.URL
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+}
+
+// newGitHubAppTokenSource returns a token source for the installation of a GitHub App, the private
+// key is read from a PEM encoded file. Every call to Token mints a new token.
+func newGitHubAppTokenSource(ctx context.Context, baseURL *url.URL, appID, installationID int64, privateKeyFile string) (oauth2.TokenSource, error) {
+	data, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read GitHub App private key: %v", err)
+	}
+	key, err := parseRSAPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse GitHub App private key: %v", err)
+	}
+	source := &gitHubAppTokenSource{
+		ctx:            ctx,
+		baseURL:        baseURL,
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+	}
+	return source, nil
+}
+
+// Token mints a new installation access token
+func (s *gitHubAppTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := s.baseURL.ResolveReference(&url.URL{Path: fmt.Sprintf("app/installations/%d/access_tokens", s.installationID)})
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	result := struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{}
+	if err := doJSON(defaultClient, req, &result); err != nil {
+		return nil, fmt.Errorf("could not create GitHub App installation token: %v", err)
+	}
+	return &oauth2.Token{AccessToken: result.Token, Expiry: result.ExpiresAt}, nil
+}
+
+// jwt returns a JSON Web Token that authenticates as the GitHub App
+func (s *gitHubAppTokenSource) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	// the issued at time is set in the past to allow for clock drift and GitHub doesn't accept
+	// tokens that expire more than 10 minutes into the future
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey parses a PEM encoded PKCS #1 or PKCS #8 RSA private key
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not a RSA key")
+	}
+	return rsaKey, nil
+}
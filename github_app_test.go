@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitHubProvider_FetchAsGitHubApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "private-key.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tokensMinted := 0
+	server := httptest.NewServer(http.StripPrefix("/api/v3", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/installations/5678/access_tokens":
+			tokensMinted++
+			if accept := r.Header.Get("Accept"); accept != "application/vnd.github+json" {
+				t.Errorf("expected the GitHub media type to be accepted, got '%s'", accept)
+			}
+			if err := verifyGitHubAppJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &key.PublicKey, 1234); err != nil {
+				t.Errorf("invalid JWT: %v", err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"token": "installation_token", "expires_at": "%s"}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		case "/repos/acme/one/pulls":
+			if r.Header.Get("Authorization") != "Bearer installation_token" {
+				t.Errorf("expected installation token, got '%s'", r.Header.Get("Authorization"))
+			}
+			fmt.Fprint(w, `[{"number": 1, "title": "pr", "user": {"login": "jane"}, "html_url": "https://github.example.com/acme/one/pull/1",
+				"updated_at": "2022-10-03T08:12:34Z", "draft": false}]`)
+		case "/repos/acme/one/pulls/1/reviews":
+			if r.Header.Get("Authorization") != "Bearer installation_token" {
+				t.Errorf("expected installation token, got '%s'", r.Header.Get("Authorization"))
+			}
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})))
+	defer server.Close()
+
+	provider := &GitHubProvider{
+		URL:               server.URL,
+		AppID:             1234,
+		AppInstallationID: 5678,
+		AppPrivateKeyFile: keyFile,
+		Repos:             []string{"acme/one"},
+	}
+
+	prs := fetchAll(t, provider)
+	if len(prs) != 1 {
+		t.Errorf("expected 1 pull request, got %d", len(prs))
+	}
+	if tokensMinted != 1 {
+		t.Errorf("expected the installation token to be minted once and reused, got %d", tokensMinted)
+	}
+}
+
+func TestGitHubProvider_ValidateGitHubApp(t *testing.T) {
+	provider := &GitHubProvider{
+		Token: "secret",
+		AppID: 1234,
+		Repos: []string{"user1/repo1"},
+	}
+	if errors := provider.Validate(); len(errors) != 3 {
+		t.Errorf("expected 3 validation errors, got %d: %v", len(errors), errors)
+	}
+}
+
+// verifyGitHubAppJWT checks the signature and the issuer of a JSON Web Token
+func verifyGitHubAppJWT(token string, key *rsa.PublicKey, appID int64) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected 3 parts, got %d", len(parts))
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature); err != nil {
+		return err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	claims := make(map[string]int64)
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	if claims["iss"] != appID {
+		return fmt.Errorf("expected issuer %d, got %d", appID, claims["iss"])
+	}
+	if claims["exp"] <= time.Now().Unix() {
+		return fmt.Errorf("token has expired")
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// gitHubGraphQLBatchSize is the number of repositories that are queried in a single request
+	gitHubGraphQLBatchSize = 25
+	// gitHubGraphQLPageSize is the number of pull requests fetched per repository and request
+	gitHubGraphQLPageSize = 50
+)
+
+// gitHubGraphQLFragment selects the fields of the open pull requests that are needed for a PullRequest
+const gitHubGraphQLFragment = `
+fragment pullRequests on PullRequestConnection {
+  pageInfo { hasNextPage endCursor }
+  nodes {
+    number
+    title
+    url
+    updatedAt
+    isDraft
+    reviewDecision
+    author { login }
+    assignees(first: 1) { nodes { login } }
+    reviewRequests(first: 20) { nodes { requestedReviewer { ... on User { login } ... on Team { combinedSlug } } } }
+    labels(first: 20) { nodes { name } }
+    latestOpinionatedReviews(first: 100) { nodes { state submittedAt author { login } } }
+    latestReviews(first: 100) { nodes { state submittedAt author { login } } }
+  }
+}`
+
+type gitHubGraphQLPullRequest struct {
+	Number         int       `json:"number"`
+	Title          string    `json:"title"`
+	URL            string    `json:"url"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	IsDraft        bool      `json:"isDraft"`
+	ReviewDecision string    `json:"reviewDecision"`
+	Author         *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Assignees struct {
+		Nodes []struct {
+			Login string `json:"login"`
+		} `json:"nodes"`
+	} `json:"assignees"`
+	ReviewRequests struct {
+		Nodes []struct {
+			// RequestedReviewer has a login for users and a combined slug for teams
+			RequestedReviewer struct {
+				Login        string `json:"login"`
+				CombinedSlug string `json:"combinedSlug"`
+			} `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"reviewRequests"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	// LatestOpinionatedReviews are the latest approval or change request of each reviewer, and
+	// LatestReviews the latest review of any state, which can be a comment after an approval
+	LatestOpinionatedReviews struct {
+		Nodes []*gitHubGraphQLReview `json:"nodes"`
+	} `json:"latestOpinionatedReviews"`
+	LatestReviews struct {
+		Nodes []*gitHubGraphQLReview `json:"nodes"`
+	} `json:"latestReviews"`
+}
+
+type gitHubGraphQLReview struct {
+	State       string    `json:"state"`
+	SubmittedAt time.Time `json:"submittedAt"`
+	Author      *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+type gitHubGraphQLRepository struct {
+	PullRequests struct {
+		PageInfo struct {
+			HasNextPage bool   `json:"hasNextPage"`
+			EndCursor   string `json:"endCursor"`
+		} `json:"pageInfo"`
+		Nodes []*gitHubGraphQLPullRequest `json:"nodes"`
+	} `json:"pullRequests"`
+}
+
+type gitHubGraphQLResponse struct {
+	// Data contains the repositories keyed by their alias in the query
+	Data   map[string]*gitHubGraphQLRepository `json:"data"`
+	Errors []struct {
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	} `json:"errors"`
+}
+
+// gitHubGraphQLPage is the next page of pull requests to fetch for a repository
+type gitHubGraphQLPage struct {
+	repo   string
+	cursor string
+}
+
+// gitHubGraphQLURL returns the GraphQL endpoint for a REST API base URL, GitHub Enterprise Server
+// serves the REST API from /api/v3/ and GraphQL from /api/graphql
+func gitHubGraphQLURL(baseURL *url.URL) string {
+	u := *baseURL
+	u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+	return u.String()
+}
+
+// fetchGraphQL returns a channel that emits the open pull requests of the repositories. The pull
+// requests, including their reviews, of several repositories are fetched with a single GraphQL
+// query, which uses a lot less of the rate limit than the REST API.
+func (p *GitHubProvider) fetchGraphQL(ctx context.Context, client *http.Client, endpoint string, repos []string, log Logger, errs *FetchErrors) <-chan *PullRequest {
+	out := make(chan *PullRequest)
+
+	go func() {
+		defer close(out)
+
+		var pending []*gitHubGraphQLPage
+		for _, repo := range repos {
+			pending = append(pending, &gitHubGraphQLPage{repo: repo})
+		}
+
+		// repositories with more open pull requests than fits on one page are queued up again
+		// with the cursor for their next page
+		for len(pending) > 0 {
+			size := gitHubGraphQLBatchSize
+			if len(pending) < size {
+				size = len(pending)
+			}
+			batch := pending[:size]
+			pending = pending[size:]
+
+			log.Debugf("fetching PRs for %d GitHub repos with GraphQL\n", len(batch))
+			next, err := p.queryGraphQL(ctx, client, endpoint, batch, out, errs)
+			if err != nil {
+				for _, page := range batch {
+					errs.Add(p.Name(), page.repo, err)
+				}
+				continue
+			}
+			pending = append(pending, next...)
+		}
+	}()
+
+	return out
+}
+
+// queryGraphQL sends the pull requests of a batch of repositories to out and returns the pages that
+// still have to be fetched
+func (p *GitHubProvider) queryGraphQL(ctx context.Context, client *http.Client, endpoint string, batch []*gitHubGraphQLPage, out chan<- *PullRequest, errs *FetchErrors) ([]*gitHubGraphQLPage, error) {
+	var params []string
+	var selections []string
+	variables := make(map[string]interface{})
+
+	for i, page := range batch {
+		parts := strings.Split(page.repo, "/")
+		params = append(params, fmt.Sprintf("$owner%d: String!, $name%d: String!, $cursor%d: String", i, i, i))
+		selections = append(selections, fmt.Sprintf(
+			"  r%d: repository(owner: $owner%d, name: $name%d) {\n    pullRequests(states: OPEN, first: %d, after: $cursor%d, orderBy: {field: UPDATED_AT, direction: DESC}) { ...pullRequests }\n  }",
+			i, i, i, gitHubGraphQLPageSize, i,
+		))
+		variables[fmt.Sprintf("owner%d", i)] = parts[0]
+		variables[fmt.Sprintf("name%d", i)] = parts[1]
+		if page.cursor != "" {
+			variables[fmt.Sprintf("cursor%d", i)] = page.cursor
+		}
+	}
+
+	query := fmt.Sprintf("query(%s) {\n%s\n}\n%s", strings.Join(params, ", "), strings.Join(selections, "\n"), gitHubGraphQLFragment)
+
+	result := &gitHubGraphQLResponse{}
+	payload := map[string]interface{}{"query": query, "variables": variables}
+	if err := postJSON(ctx, client, endpoint, payload, result); err != nil {
+		return nil, err
+	}
+
+	// errors are reported per repository, e.g. when a repository can't be found, while the other
+	// repositories in the query are still returned
+	for _, e := range result.Errors {
+		repo := "unknown repository"
+		if len(e.Path) > 0 {
+			var i int
+			if _, err := fmt.Sscanf(fmt.Sprint(e.Path[0]), "r%d", &i); err == nil && i < len(batch) {
+				repo = batch[i].repo
+			}
+		}
+		errs.Add(p.Name(), repo, errors.New(e.Message))
+	}
+
+	var next []*gitHubGraphQLPage
+	for i, page := range batch {
+		repository := result.Data[fmt.Sprintf("r%d", i)]
+		if repository == nil {
+			continue
+		}
+		for _, pr := range repository.PullRequests.Nodes {
+			out <- pr.toPullRequest(page.repo)
+		}
+		if repository.PullRequests.PageInfo.HasNextPage {
+			next = append(next, &gitHubGraphQLPage{repo: page.repo, cursor: repository.PullRequests.PageInfo.EndCursor})
+		}
+	}
+	return next, nil
+}
+
+// toPullRequest transforms the GitHub pull request into a provider agnostic struct
+func (pr *gitHubGraphQLPullRequest) toPullRequest(repo string) *PullRequest {
+	requiresChanges, approved, reviews := pr.reviewState()
+	pullRequest := &PullRequest{
+		ID:              pr.Number,
+		Updated:         pr.UpdatedAt,
+		WebLink:         pr.URL,
+		Title:           pr.Title,
+		Repository:      repo,
+		RequiresChanges: requiresChanges,
+		Approved:        approved,
+		Draft:           pr.IsDraft,
+		Reviews:         reviews,
+		Approvals:       countApprovals(reviews),
+	}
+	if pr.Author != nil {
+		pullRequest.Author = pr.Author.Login
+	}
+	if len(pr.Assignees.Nodes) > 0 {
+		pullRequest.Assignee = pr.Assignees.Nodes[0].Login
+	}
+	for _, request := range pr.ReviewRequests.Nodes {
+		if request.RequestedReviewer.Login != "" {
+			pullRequest.Reviewers = append(pullRequest.Reviewers, request.RequestedReviewer.Login)
+		}
+		if request.RequestedReviewer.CombinedSlug != "" {
+			pullRequest.ReviewerTeams = append(pullRequest.ReviewerTeams, request.RequestedReviewer.CombinedSlug)
+		}
+	}
+	for _, label := range pr.Labels.Nodes {
+		pullRequest.Labels = append(pullRequest.Labels, label.Name)
+	}
+	return pullRequest
+}
+
+// reviewState returns the requiresChanges and approved flags and the latest review of each reviewer.
+// The review decision is only set when the branch protection requires reviews, otherwise the most
+// recent approval or change request decides the state, the same way as with the REST API.
+func (pr *gitHubGraphQLPullRequest) reviewState() (bool, bool, []*Review) {
+	reviews := pr.LatestOpinionatedReviews.Nodes
+	sort.SliceStable(reviews, func(i, j int) bool {
+		return reviews[i].SubmittedAt.Before(reviews[j].SubmittedAt)
+	})
+
+	// both lists together are in the order the REST API returns the reviews in, so that comments
+	// are kept the same way and the reviewers end up in the same order
+	all := append(append([]*gitHubGraphQLReview{}, reviews...), pr.LatestReviews.Nodes...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].SubmittedAt.Before(all[j].SubmittedAt)
+	})
+	latest := newLatestReviews()
+	for _, review := range all {
+		if review.Author != nil {
+			latest.add(review.Author.Login, review.State)
+		}
+	}
+
+	switch pr.ReviewDecision {
+	case "CHANGES_REQUESTED":
+		return true, false, latest.list()
+	case "APPROVED":
+		return false, true, latest.list()
+	}
+
+	requiresChanges := false
+	approved := false
+	for _, review := range reviews {
+		if review.State == "CHANGES_REQUESTED" {
+			requiresChanges = true
+			approved = false
+		}
+		if review.State == "APPROVED" {
+			approved = true
+			requiresChanges = false
+		}
+	}
+	return requiresChanges, approved, latest.list()
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGitHubProvider_FetchGraphQL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/graphql" || r.Method != http.MethodPost {
+			t.Errorf("unexpected request to %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+
+		body := struct {
+			Query     string            `json:"query"`
+			Variables map[string]string `json:"variables"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+			return
+		}
+
+		// the second page of pull requests for acme/one
+		if body.Variables["cursor0"] == "c1" {
+			if body.Variables["owner0"] != "acme" || body.Variables["name0"] != "one" {
+				t.Errorf("expected the next page to be for acme/one, got %v", body.Variables)
+			}
+			fmt.Fprint(w, `{"data": {"r0": {"pullRequests": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"number": 2, "title": "second", "url": "https://github.com/acme/one/pull/2", "updatedAt": "2022-10-03T08:12:34Z",
+				 "author": {"login": "john"}, "latestOpinionatedReviews": {"nodes": [
+					{"state": "APPROVED", "submittedAt": "2022-10-03T10:00:00Z", "author": {"login": "joe"}},
+					{"state": "CHANGES_REQUESTED", "submittedAt": "2022-10-03T09:00:00Z", "author": {"login": "jim"}}
+				 ]}}
+			]}}}}`)
+			return
+		}
+
+		if len(body.Variables) != 6 {
+			t.Errorf("expected 3 repositories to be queried, got variables %v", body.Variables)
+		}
+		fmt.Fprint(w, `{"data": {
+			"r0": {"pullRequests": {"pageInfo": {"hasNextPage": true, "endCursor": "c1"}, "nodes": [
+				{"number": 1, "title": "first", "url": "https://github.com/acme/one/pull/1", "updatedAt": "2022-10-03T08:12:34Z", "isDraft": true,
+				 "reviewDecision": "CHANGES_REQUESTED", "author": {"login": "jane"}, "assignees": {"nodes": [{"login": "john"}]},
+				 "reviewRequests": {"nodes": [{"requestedReviewer": {"login": "joe"}}, {"requestedReviewer": {"combinedSlug": "acme/backend"}}]},
+				 "labels": {"nodes": [{"name": "bug"}]}}
+			]}},
+			"r1": {"pullRequests": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"number": 3, "title": "third", "url": "https://github.com/acme/two/pull/3", "updatedAt": "2022-10-03T08:12:34Z",
+				 "reviewDecision": "APPROVED", "author": null}
+			]}},
+			"r2": null
+		}, "errors": [{"message": "Could not resolve to a Repository with the name 'acme/missing'.", "path": ["r2"]}]}`)
+	}))
+	defer server.Close()
+
+	provider := &GitHubProvider{
+		Repos:   []string{"acme/one", "acme/two", "acme/missing"},
+		GraphQL: true,
+		URL:     server.URL,
+	}
+
+	prs := fetchAll(t, provider)
+	if requests != 2 {
+		t.Errorf("expected 2 GraphQL requests, got %d", requests)
+	}
+	if len(prs) != 3 {
+		t.Fatalf("expected 3 pull requests, got %d", len(prs))
+	}
+
+	first := prs[1]
+	if first.Author != "jane" || first.Assignee != "john" || first.Repository != "acme/one" || !first.Draft {
+		t.Errorf("unexpected first pull request %+v", first)
+	}
+	if len(first.Labels) != 1 || first.Labels[0] != "bug" {
+		t.Errorf("expected first pull request to be labeled 'bug', got %v", first.Labels)
+	}
+	if len(first.Reviewers) != 1 || first.Reviewers[0] != "joe" {
+		t.Errorf("expected 'joe' to be the only requested reviewer of the first pull request, got %v", first.Reviewers)
+	}
+	if len(first.ReviewerTeams) != 1 || first.ReviewerTeams[0] != "acme/backend" {
+		t.Errorf("expected 'acme/backend' to be the requested team of the first pull request, got %v", first.ReviewerTeams)
+	}
+	if !first.RequiresChanges || first.Approved {
+		t.Errorf("expected first pull request to require changes, got %+v", first)
+	}
+	if prs[2].RequiresChanges || !prs[2].Approved {
+		t.Errorf("expected the most recent review to approve the second pull request, got %+v", prs[2])
+	}
+	if len(prs[2].Reviews) != 2 || *prs[2].Reviews[0] != (Review{Reviewer: "jim", State: ReviewChangesRequested}) || prs[2].Approvals != 1 {
+		t.Errorf("expected a change request by jim and an approval by joe, got %+v", prs[2])
+	}
+	if prs[3].Repository != "acme/two" || !prs[3].Approved {
+		t.Errorf("expected third pull request to be an approved pull request from acme/two, got %+v", prs[3])
+	}
+}
+
+func TestGitHubProvider_FetchGraphQLReviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/graphql":
+			fmt.Fprint(w, `{"data": {"r0": {"pullRequests": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"number": 1, "title": "pr", "url": "https://github.com/acme/one/pull/1", "updatedAt": "2022-10-03T08:12:34Z",
+				 "author": {"login": "jane"},
+				 "latestOpinionatedReviews": {"nodes": [
+					{"state": "CHANGES_REQUESTED", "submittedAt": "2022-10-03T10:00:00Z", "author": {"login": "jim"}},
+					{"state": "APPROVED", "submittedAt": "2022-10-03T09:00:00Z", "author": {"login": "joe"}}
+				 ]},
+				 "latestReviews": {"nodes": [
+					{"state": "COMMENTED", "submittedAt": "2022-10-03T12:00:00Z", "author": {"login": "jack"}},
+					{"state": "COMMENTED", "submittedAt": "2022-10-03T11:00:00Z", "author": {"login": "joe"}},
+					{"state": "CHANGES_REQUESTED", "submittedAt": "2022-10-03T10:00:00Z", "author": {"login": "jim"}}
+				 ]}}
+			]}}}}`)
+		case "/api/v3/repos/acme/one/pulls":
+			fmt.Fprint(w, `[{"number": 1, "title": "pr", "user": {"login": "jane"}, "html_url": "https://github.com/acme/one/pull/1",
+				"updated_at": "2022-10-03T08:12:34Z", "draft": false}]`)
+		case "/api/v3/repos/acme/one/pulls/1/reviews":
+			fmt.Fprint(w, `[
+				{"user": {"login": "joe"}, "state": "APPROVED"},
+				{"user": {"login": "jim"}, "state": "CHANGES_REQUESTED"},
+				{"user": {"login": "joe"}, "state": "COMMENTED"},
+				{"user": {"login": "jack"}, "state": "COMMENTED"}
+			]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	rest := fetchAll(t, &GitHubProvider{Repos: []string{"acme/one"}, URL: server.URL})[1]
+	graphQL := fetchAll(t, &GitHubProvider{Repos: []string{"acme/one"}, URL: server.URL, GraphQL: true})[1]
+	if rest == nil || graphQL == nil {
+		t.Fatalf("expected the pull request from both APIs, got %+v and %+v", rest, graphQL)
+	}
+
+	// a comment doesn't replace an approval, and a comment on its own is a review
+	expected := []*Review{
+		{Reviewer: "joe", State: ReviewApproved},
+		{Reviewer: "jim", State: ReviewChangesRequested},
+		{Reviewer: "jack", State: ReviewCommented},
+	}
+	for name, pr := range map[string]*PullRequest{"REST": rest, "GraphQL": graphQL} {
+		if len(pr.Reviews) != len(expected) {
+			t.Errorf("expected %d reviews from the %s API, got %d", len(expected), name, len(pr.Reviews))
+			continue
+		}
+		for i := range expected {
+			if *pr.Reviews[i] != *expected[i] {
+				t.Errorf("expected review %d from the %s API to be %+v, got %+v", i, name, expected[i], pr.Reviews[i])
+			}
+		}
+		if pr.Approvals != 1 {
+			t.Errorf("expected 1 approval from the %s API, got %d", name, pr.Approvals)
+		}
+	}
+}
+
+func TestGitHubGraphQLURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{baseURL: "https://api.github.com/", expected: "https://api.github.com/graphql"},
+		{baseURL: "https://github.example.com/api/v3/", expected: "https://github.example.com/api/graphql"},
+	}
+	for _, test := range tests {
+		u, _ := url.Parse(test.baseURL)
+		if actual := gitHubGraphQLURL(u); actual != test.expected {
+			t.Errorf("expected '%s', got '%s'", test.expected, actual)
+		}
+	}
+}
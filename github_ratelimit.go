@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// gitHubConcurrency is the number of concurrent GitHub API requests when none has been configured
+	gitHubConcurrency = 10
+	// gitHubMaxRetries is the number of times a rate limited request is retried
+	gitHubMaxRetries = 3
+	// gitHubMaxRateLimitWait is the longest time a rate limited request will wait before it's retried
+	gitHubMaxRateLimitWait = 15 * time.Minute
+)
+
+// gitHubRateLimitTransport retries requests that have hit the primary or secondary GitHub rate limit
+// once the limit has been reset, and keeps track of the remaining rate limit budget
+type gitHubRateLimitTransport struct {
+	Base http.RoundTripper
+	log  Logger
+
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// RoundTrip sends the request and retries it if it was rate limited
+func (t *gitHubRateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.Base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		t.update(resp)
+
+		wait, limited := gitHubRateLimitWait(resp, time.Now())
+		if !limited || attempt >= gitHubMaxRetries || wait > gitHubMaxRateLimitWait {
+			return resp, nil
+		}
+		// a request with a body can only be retried if the body can be read again
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		t.log.Infof("GitHub rate limit hit for %s, retrying in %s\n", req.URL.Path, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// update records the rate limit budget from the response headers
+func (t *gitHubRateLimitTransport) update(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limit = limit
+	t.remaining = remaining
+	t.reset = time.Unix(reset, 0)
+}
+
+// report logs the last known rate limit budget
+func (t *gitHubRateLimitTransport) report(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limit == 0 {
+		return
+	}
+	t.log.Debugf("%s rate limit: %d of %d requests remaining, resets at %s\n", name, t.remaining, t.limit, t.reset.Format(time.RFC3339))
+}
+
+// gitHubRateLimitWait returns how long to wait before a rate limited request can be retried, and
+// false if the request wasn't rate limited
+func gitHubRateLimitWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	// secondary rate limits tell how many seconds to wait
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	// the primary rate limit has been used up when there are no requests remaining, a 403 with
+	// requests remaining is a permission error
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	wait := time.Unix(reset, 0).Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	// allow for clock drift between this machine and GitHub
+	return wait + time.Second, true
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGitHubProvider_FetchRetriesRateLimited(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.StripPrefix("/api/v3", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		count := requests[r.URL.Path]
+		mu.Unlock()
+
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()))
+		switch r.URL.Path {
+		case "/repos/acme/one/pulls":
+			// the secondary rate limit is hit on the first request
+			if count == 1 {
+				w.Header().Set("Retry-After", "0")
+				http.Error(w, `{"message": "You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `[{"number": 1, "title": "pr", "user": {"login": "jane"}, "html_url": "https://github.example.com/acme/one/pull/1",
+				"updated_at": "2022-10-03T08:12:34Z", "draft": false}]`)
+		case "/repos/acme/one/pulls/1/reviews":
+			fmt.Fprint(w, `[{"state": "APPROVED"}]`)
+		case "/repos/acme/denied/pulls":
+			// a forbidden request that isn't rate limited is not retried
+			http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})))
+	defer server.Close()
+
+	provider := &GitHubProvider{
+		URL:   server.URL,
+		Token: "secret",
+		Repos: []string{"acme/one", "acme/denied"},
+	}
+
+	errs := NewFetchErrors(NewStdOutLogger(false))
+	in, err := provider.Fetch(context.Background(), NewStdOutLogger(false), errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prs := make(map[int]*PullRequest)
+	for pr := range in {
+		prs[pr.ID] = pr
+	}
+	if len(prs) != 1 {
+		t.Fatalf("expected 1 pull request, got %d", len(prs))
+	}
+	if errors := errs.Errors(); len(errors) != 1 || errors[0].Key != "acme/denied" {
+		t.Errorf("expected an error for acme/denied, got %v", errors)
+	}
+	if !prs[1].Approved {
+		t.Errorf("expected the pull request to be approved")
+	}
+	if requests["/repos/acme/one/pulls"] != 2 {
+		t.Errorf("expected the rate limited request to be retried once, got %d requests", requests["/repos/acme/one/pulls"])
+	}
+	if requests["/repos/acme/denied/pulls"] != 1 {
+		t.Errorf("expected the forbidden request not to be retried, got %d requests", requests["/repos/acme/denied/pulls"])
+	}
+}
+
+func TestGitHubProvider_FetchConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	server := httptest.NewServer(http.StripPrefix("/api/v3", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		if strings.HasSuffix(r.URL.Path, "/reviews") {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprintf(w, `[{"number": 1, "title": "pr", "user": {"login": "jane"}, "html_url": "https://github.example.com%s/1",
+			"updated_at": "2022-10-03T08:12:34Z", "draft": false}]`, r.URL.Path)
+	})))
+	defer server.Close()
+
+	provider := &GitHubProvider{
+		URL:         server.URL,
+		Token:       "secret",
+		Concurrency: 2,
+	}
+	for i := 0; i < 10; i++ {
+		provider.Repos = append(provider.Repos, fmt.Sprintf("acme/repo%d", i))
+	}
+
+	ch, err := provider.Fetch(context.Background(), NewStdOutLogger(false), NewFetchErrors(NewStdOutLogger(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for range ch {
+		count++
+	}
+	if count != 10 {
+		t.Errorf("expected 10 pull requests, got %d", count)
+	}
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", maxRunning)
+	}
+}
+
+func TestGitHubRateLimitWait(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tests := []struct {
+		status  int
+		headers map[string]string
+		wait    time.Duration
+		limited bool
+	}{
+		{http.StatusOK, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1060"}, 0, false},
+		{http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1060"}, 61 * time.Second, true},
+		{http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "900"}, time.Second, true},
+		{http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "10", "X-RateLimit-Reset": "1060"}, 0, false},
+		{http.StatusForbidden, map[string]string{"Retry-After": "30"}, 30 * time.Second, true},
+		{http.StatusTooManyRequests, map[string]string{"Retry-After": "5"}, 5 * time.Second, true},
+	}
+	for i, test := range tests {
+		resp := &http.Response{StatusCode: test.status, Header: make(http.Header)}
+		for key, value := range test.headers {
+			resp.Header.Set(key, value)
+		}
+		wait, limited := gitHubRateLimitWait(resp, now)
+		if wait != test.wait || limited != test.limited {
+			t.Errorf("%d: expected (%s, %t), got (%s, %t)", i, test.wait, test.limited, wait, limited)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// fetchSearch returns a channel that emits the open pull requests found by the search queries
+func (p *GitHubProvider) fetchSearch(ctx context.Context, client *github.Client, pool *workerPool, log Logger, errs *FetchErrors) <-chan *PullRequest {
+	out := make(chan *PullRequest)
+
+	// create a sync group that is used to close the out channel when all queries has been searched
+	var wg sync.WaitGroup
+
+	for _, query := range p.Queries {
+		wg.Add(1)
+
+		go func(query string) {
+			defer wg.Done()
+
+			options := &github.SearchOptions{
+				Sort:        "updated",
+				Order:       "desc",
+				ListOptions: github.ListOptions{PerPage: 100},
+			}
+			for {
+				log.Debugf("searching GitHub for '%s'\n", query)
+				result, resp, err := client.Search.Issues(ctx, query, options)
+				if err != nil {
+					errs.Add(p.Name(), fmt.Sprintf("'%s' (search)", query), err)
+					return
+				}
+
+				for _, issue := range result.Issues {
+					// the search API returns issues as well unless the query contains is:pr
+					if !issue.IsPullRequest() || issue.GetState() != "open" {
+						continue
+					}
+					issue := issue
+
+					// the search result doesn't contain the draft state, so the pull request is
+					// fetched before it's transformed and pushed onto out
+					wg.Add(1)
+					pool.Go(func() {
+						defer wg.Done()
+
+						// the repository URL looks like https://api.github.com/repos/owner/repo
+						parts := strings.Split(issue.GetRepositoryURL(), "/")
+						if len(parts) < 2 {
+							errs.Add(p.Name(), issue.GetHTMLURL(), fmt.Errorf("couldn't find the repository"))
+							return
+						}
+						owner, repo := parts[len(parts)-2], parts[len(parts)-1]
+
+						pr, _, err := client.PullRequests.Get(ctx, owner, repo, issue.GetNumber())
+						if err != nil {
+							errs.Add(p.Name(), fmt.Sprintf("%s/%s#%d", owner, repo, issue.GetNumber()), err)
+							return
+						}
+						out <- p.fromGitHubPullRequest(ctx, client, owner, repo, pr, errs)
+					})
+				}
+
+				// the GitHub API returns 0 as the NextPage if there are no more pages of result
+				if resp.NextPage == 0 {
+					break
+				}
+				options.Page = resp.NextPage
+			}
+		}(query)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// uniquePullRequests removes any duplicated pull requests, which are identified by their web link
+func uniquePullRequests(in <-chan *PullRequest) <-chan *PullRequest {
+	out := make(chan *PullRequest)
+
+	go func() {
+		seen := make(map[string]bool)
+		for pr := range in {
+			if !seen[pr.WebLink] {
+				seen[pr.WebLink] = true
+				out <- pr
+			}
+		}
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitHubProvider_FetchOrganisationPaginated(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.StripPrefix("/api/v3", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/orgs/acme/repos":
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `[{"full_name": "acme/three"}, {"full_name": "acme/fork", "fork": true}]`)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/acme/repos?page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"full_name": "acme/one"}, {"full_name": "acme/two"}, {"full_name": "acme/archived", "archived": true}]`)
+		case r.URL.Path == "/users/jane/repos":
+			fmt.Fprint(w, `[{"full_name": "jane/four"}]`)
+		case strings.HasSuffix(r.URL.Path, "/reviews"):
+			fmt.Fprint(w, `[]`)
+		case strings.HasSuffix(r.URL.Path, "/pulls"):
+			repo := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/repos/"), "/pulls")
+			fmt.Fprintf(w, `[{"number": 1, "title": "%s", "user": {"login": "jane"}, "html_url": "https://github.com/%s/pull/1",
+				"updated_at": "2022-10-03T08:12:34Z", "draft": false}]`, repo, repo)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})))
+	defer server.Close()
+
+	provider := &GitHubProvider{
+		Organisations:   []string{"acme"},
+		Users:           []string{"jane"},
+		ExcludeArchived: true,
+		ExcludeForks:    true,
+		URL:             server.URL,
+	}
+
+	prs, err := provider.Fetch(context.Background(), NewStdOutLogger(false), NewFetchErrors(NewStdOutLogger(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repositories := make(map[string]bool)
+	for pr := range prs {
+		repositories[pr.Repository] = true
+	}
+
+	expected := []string{"acme/one", "acme/two", "acme/three", "jane/four"}
+	if len(repositories) != len(expected) {
+		t.Errorf("expected %d repositories, got %d: %v", len(expected), len(repositories), repositories)
+	}
+	for _, repo := range expected {
+		if !repositories[repo] {
+			t.Errorf("expected a pull request from %s", repo)
+		}
+	}
+}
+
+func TestGitHubProvider_FetchSearchQueries(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.StripPrefix("/api/v3", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pullRequest := `{"number": %d, "title": "pr", "user": {"login": "jane"}, "html_url": "https://github.com/%s/pull/%d",
+			"updated_at": "2022-10-03T08:12:34Z", "draft": %t}`
+		switch r.URL.Path {
+		case "/search/issues":
+			if r.URL.Query().Get("q") != "is:open review-requested:acme/backend" {
+				t.Errorf("unexpected query '%s'", r.URL.Query().Get("q"))
+			}
+			fmt.Fprintf(w, `{"total_count": 3, "items": [
+				{"number": 1, "state": "open", "repository_url": "%[1]s/repos/acme/one", "pull_request": {"url": "%[1]s/repos/acme/one/pulls/1"}},
+				{"number": 5, "state": "open", "repository_url": "%[1]s/repos/acme/two", "pull_request": {"url": "%[1]s/repos/acme/two/pulls/5"}},
+				{"number": 6, "state": "open", "repository_url": "%[1]s/repos/acme/two"}
+			]}`, server.URL)
+		case "/repos/acme/one/pulls":
+			fmt.Fprintf(w, "["+pullRequest+"]", 1, "acme/one", 1, false)
+		case "/repos/acme/one/pulls/1":
+			fmt.Fprintf(w, pullRequest, 1, "acme/one", 1, false)
+		case "/repos/acme/two/pulls/5":
+			fmt.Fprintf(w, pullRequest, 5, "acme/two", 5, true)
+		case "/repos/acme/one/pulls/1/reviews", "/repos/acme/two/pulls/5/reviews":
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})))
+	defer server.Close()
+
+	provider := &GitHubProvider{
+		Repos:   []string{"acme/one"},
+		Queries: []string{"is:open review-requested:acme/backend"},
+		URL:     server.URL,
+	}
+
+	in, err := provider.Fetch(context.Background(), NewStdOutLogger(false), NewFetchErrors(NewStdOutLogger(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prs := make(map[int]*PullRequest)
+	count := 0
+	for pr := range in {
+		prs[pr.ID] = pr
+		count++
+	}
+
+	// pull request #1 is both in the repository and in the search result
+	if count != 2 {
+		t.Fatalf("expected 2 pull requests, got %d", count)
+	}
+	if prs[1].Repository != "acme/one" {
+		t.Errorf("expected first pull request to be from acme/one, got %+v", prs[1])
+	}
+	if prs[5].Repository != "acme/two" || !prs[5].Draft {
+		t.Errorf("expected second pull request to be a draft from acme/two, got %+v", prs[5])
+	}
+}
+
+func TestGitHubProvider_FetchReviews(t *testing.T) {
+	server := httptest.NewServer(http.StripPrefix("/api/v3", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/one/pulls":
+			fmt.Fprint(w, `[{"number": 1, "title": "pr", "user": {"login": "jane"}, "html_url": "https://github.com/acme/one/pull/1",
+				"updated_at": "2022-10-03T08:12:34Z", "draft": false,
+				"requested_reviewers": [{"login": "jim"}, {"login": "john"}], "requested_teams": [{"slug": "backend"}]}]`)
+		case "/repos/acme/one/pulls/1/reviews":
+			fmt.Fprint(w, `[
+				{"user": {"login": "joe"}, "state": "COMMENTED"},
+				{"user": {"login": "joe"}, "state": "APPROVED"},
+				{"user": {"login": "jim"}, "state": "CHANGES_REQUESTED"},
+				{"user": {"login": "joe"}, "state": "COMMENTED"},
+				{"user": {"login": "jack"}, "state": "APPROVED"},
+				{"user": {"login": "jack"}, "state": "DISMISSED"},
+				{"user": {"login": "jill"}, "state": "PENDING"}
+			]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})))
+	defer server.Close()
+
+	provider := &GitHubProvider{
+		Repos: []string{"acme/one"},
+		URL:   server.URL,
+	}
+	prs := fetchAll(t, provider)
+	if len(prs) != 1 {
+		t.Fatalf("expected 1 pull request, got %d", len(prs))
+	}
+	pr := prs[1]
+
+	if len(pr.Reviewers) != 2 || pr.Reviewers[0] != "jim" || pr.Reviewers[1] != "john" {
+		t.Errorf("expected jim and john to be requested reviewers, got %v", pr.Reviewers)
+	}
+	if len(pr.ReviewerTeams) != 1 || pr.ReviewerTeams[0] != "acme/backend" {
+		t.Errorf("expected acme/backend to be a requested team, got %v", pr.ReviewerTeams)
+	}
+
+	// a comment doesn't replace an approval and a dismissed approval doesn't count
+	expected := []*Review{
+		{Reviewer: "joe", State: ReviewApproved},
+		{Reviewer: "jim", State: ReviewChangesRequested},
+		{Reviewer: "jack", State: ReviewCommented},
+	}
+	if len(pr.Reviews) != len(expected) {
+		t.Fatalf("expected %d reviews, got %d", len(expected), len(pr.Reviews))
+	}
+	for i := range expected {
+		if *pr.Reviews[i] != *expected[i] {
+			t.Errorf("expected review %d to be %+v, got %+v", i, expected[i], pr.Reviews[i])
+		}
+	}
+	if pr.Approvals != 1 {
+		t.Errorf("expected 1 approval, got %d", pr.Approvals)
+	}
+}
@@ -1,28 +1,119 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
 	"github.com/xanzy/go-gitlab"
 )
 
-func trawlGitLab(conf *Config, log Logger) <-chan *PullRequest {
+func init() {
+	RegisterProvider(newGitLabProvider)
+}
+
+// GitLabProvider fetches merge requests from GitLab projects
+type GitLabProvider struct {
+	Token            string
+	URL              string
+	Groups           []string
+	IncludeSubgroups bool
+	Users            []string
+	Repos            []string
+}
+
+func newGitLabProvider(conf *Config) []Provider {
+	if len(conf.GitLabGroups) == 0 && len(conf.GitLabUsers) == 0 && len(conf.GitLabRepos) == 0 {
+		return nil
+	}
+	return []Provider{&GitLabProvider{
+		Token:            conf.GitLabToken,
+		URL:              conf.GitlabURL,
+		Groups:           conf.GitLabGroups,
+		IncludeSubgroups: conf.GitLabIncludeSubgroups,
+		Users:            conf.GitLabUsers,
+		Repos:            conf.GitLabRepos,
+	}}
+}
+
+// Name returns the name of the provider
+func (p *GitLabProvider) Name() string {
+	return "GitLab"
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (p *GitLabProvider) Validate() []error {
+	var errors []error
+	if p.URL == "" {
+		errors = append(errors, fmt.Errorf("GitLab URL cannot be empty"))
+	}
+	return errors
+}
+
+// Fetch returns a channel that emits all open merge requests from the configured projects
+func (p *GitLabProvider) Fetch(ctx context.Context, log Logger, errs *FetchErrors) (<-chan *PullRequest, error) {
+	client, err := gitlab.NewClient(p.Token, gitlab.WithBaseURL(p.URL+"/api/v4"), gitlab.WithHTTPClient(defaultClient))
+	if err != nil {
+		return nil, err
+	}
+
 	out := make(chan *PullRequest)
 
 	// create a sync group that is used to close the out channel when all gitlab repos has been
 	// trawled
 	var wg sync.WaitGroup
 
-	client, err := gitlab.NewClient(conf.GitLabToken, gitlab.WithBaseURL(conf.GitlabURL+"/api/v4"))
-	if err != nil {
-		usageAndExit(err.Error(), 1)
+	const status = "opened"
+
+	var repos []string
+
+	// find all projects in a group, and optionally all of its subgroups
+	for _, group := range p.Groups {
+		opts := &gitlab.ListGroupProjectsOptions{
+			Archived:         gitlab.Bool(false),
+			IncludeSubGroups: gitlab.Bool(p.IncludeSubgroups),
+		}
+		for {
+			projects, resp, err := client.Groups.ListGroupProjects(group, opts, gitlab.WithContext(ctx))
+			if err != nil {
+				errs.Add(p.Name(), fmt.Sprintf("%s (group)", group), err)
+				break
+			}
+			for i := range projects {
+				repos = append(repos, projects[i].PathWithNamespace)
+			}
+			// the GitLab API returns 0 as the NextPage if there are no more pages of result
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
 	}
 
-	const status = "opened"
+	for _, user := range p.Users {
+		opts := &gitlab.ListProjectsOptions{
+			Archived: gitlab.Bool(false),
+		}
+		for {
+			projects, resp, err := client.Projects.ListUserProjects(user, opts, gitlab.WithContext(ctx))
+			if err != nil {
+				errs.Add(p.Name(), fmt.Sprintf("%s (user)", user), err)
+				break
+			}
+			for i := range projects {
+				repos = append(repos, projects[i].PathWithNamespace)
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+
+	repos = append(repos, p.Repos...)
 
 	// spin out each request to find PR on a repo into a separate goroutine
-	for _, repo := range conf.GitLabRepos {
+	for _, repo := range deduplicate(repos) {
 
 		// increment
 		wg.Add(1)
@@ -34,21 +125,56 @@ func trawlGitLab(conf *Config, log Logger) <-chan *PullRequest {
 			opts := &gitlab.ListProjectMergeRequestsOptions{
 				State: gitlab.String(status),
 			}
-			pullRequests, _, err := client.MergeRequests.ListProjectMergeRequests(repoName, opts)
-			if err != nil {
-				log.Infof("Couldn't fetch PRs from GitLab (%s): %s\n", repoName, err)
-				return
-			}
-			for _, pr := range pullRequests {
-				out <- &PullRequest{
-					ID:         pr.IID,
-					Author:     pr.Author.Username,
-					Assignee:   pr.Assignee.Username,
-					Updated:    *pr.UpdatedAt,
-					WebLink:    fmt.Sprintf("%s/%s/merge_requests/%d", conf.GitlabURL, repoName, pr.IID),
-					Title:      pr.Title,
-					Repository: repoName,
+			for {
+				pullRequests, resp, err := client.MergeRequests.ListProjectMergeRequests(repoName, opts, gitlab.WithContext(ctx))
+				if err != nil {
+					errs.Add(p.Name(), repoName, err)
+					return
+				}
+				for _, pr := range pullRequests {
+					wg.Add(1)
+
+					// get the approvals and push result onto out when done
+					go func(pr *gitlab.MergeRequest) {
+						defer wg.Done()
+
+						// a merge request with unresolved blocking discussions can't be merged, so
+						// it's treated like a review that has requested changes
+						requiresChanges := !pr.BlockingDiscussionsResolved
+						approved, reviews, teams, err := trawlGitLabApprovals(ctx, client, repoName, pr.IID)
+						if err != nil {
+							errs.Add(p.Name(), fmt.Sprintf("%s!%d", repoName, pr.IID), err)
+						}
+
+						pullRequest := &PullRequest{
+							ID:              pr.IID,
+							Author:          pr.Author.Username,
+							Updated:         *pr.UpdatedAt,
+							WebLink:         fmt.Sprintf("%s/%s/merge_requests/%d", p.URL, repoName, pr.IID),
+							Title:           pr.Title,
+							Repository:      repoName,
+							RequiresChanges: requiresChanges,
+							Approved:        approved && !requiresChanges,
+							Draft:           pr.Draft || pr.WorkInProgress,
+							ReviewerTeams:   teams,
+							Reviews:         reviews,
+							Approvals:       len(reviews),
+						}
+						if pr.Assignee != nil {
+							pullRequest.Assignee = pr.Assignee.Username
+						}
+						for _, reviewer := range pr.Reviewers {
+							pullRequest.Reviewers = append(pullRequest.Reviewers, reviewer.Username)
+						}
+						out <- pullRequest
+					}(pr)
+				}
+
+				// the GitLab API returns 0 as the NextPage if there are no more pages of result
+				if resp.NextPage == 0 {
+					return
 				}
+				opts.Page = resp.NextPage
 			}
 		}(repo)
 	}
@@ -58,5 +184,36 @@ func trawlGitLab(conf *Config, log Logger) <-chan *PullRequest {
 		close(out)
 	}()
 
-	return out
+	return out, nil
+}
+
+// trawlGitLabApprovals returns true if the merge request has all the approvals it requires, a
+// review for each user that has approved it and the groups that still have to approve it
+func trawlGitLabApprovals(ctx context.Context, client *gitlab.Client, repoName string, iid int) (bool, []*Review, []string, error) {
+	approvals, _, err := client.MergeRequestApprovals.GetConfiguration(repoName, iid, gitlab.WithContext(ctx))
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	var reviews []*Review
+	for _, approver := range approvals.ApprovedBy {
+		if approver.User != nil {
+			reviews = append(reviews, &Review{Reviewer: approver.User.Username, State: ReviewApproved})
+		}
+	}
+	// the approval rules that haven't been satisfied yet, a group can be part of several rules
+	var groups []string
+	added := make(map[string]bool)
+	for _, rule := range approvals.ApprovalRulesLeft {
+		for _, group := range rule.Groups {
+			if !added[group.FullPath] {
+				added[group.FullPath] = true
+				groups = append(groups, group.FullPath)
+			}
+		}
+	}
+	// approved is only set when the required number of approvals has been reached, and a merge
+	// request that doesn't require approvals counts as approved once anyone has approved it
+	approved := approvals.Approved && (approvals.ApprovalsRequired > 0 || len(reviews) > 0)
+	return approved, reviews, groups, nil
 }
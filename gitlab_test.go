@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitLabProvider_FetchGroupsAndUsers(t *testing.T) {
+	server := newGitLabServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/approvals") {
+			fmt.Fprint(w, `{"approved_by": []}`)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/groups/acme/projects":
+			if r.URL.Query().Get("archived") != "false" {
+				t.Errorf("expected archived projects to be skipped")
+			}
+			if r.URL.Query().Get("include_subgroups") != "true" {
+				t.Errorf("expected subgroups to be included")
+			}
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `[{"path_with_namespace": "acme/sub/two"}]`)
+				return
+			}
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"path_with_namespace": "acme/one"}]`)
+		case "/api/v4/users/jane/projects":
+			fmt.Fprint(w, `[{"path_with_namespace": "jane/three"}, {"path_with_namespace": "acme/one"}]`)
+		case "/api/v4/projects/acme%2Fone/merge_requests":
+			fmt.Fprint(w, `[{"iid": 1, "title": "one", "author": {"username": "jane"}, "assignee": {"username": "john"}, "updated_at": "2022-10-03T08:12:34Z"}]`)
+		case "/api/v4/projects/acme%2Fsub%2Ftwo/merge_requests":
+			fmt.Fprint(w, `[{"iid": 2, "title": "two", "author": {"username": "jane"}, "assignee": {"username": "john"}, "updated_at": "2022-10-03T08:12:34Z"}]`)
+		case "/api/v4/projects/jane%2Fthree/merge_requests":
+			fmt.Fprint(w, `[{"iid": 3, "title": "three", "author": {"username": "jane"}, "assignee": {"username": "john"}, "updated_at": "2022-10-03T08:12:34Z"}]`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.EscapedPath())
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	provider := &GitLabProvider{
+		Token:            "secret",
+		URL:              server.URL,
+		Groups:           []string{"acme"},
+		IncludeSubgroups: true,
+		Users:            []string{"jane"},
+	}
+
+	prs := fetchAll(t, provider)
+	if len(prs) != 3 {
+		t.Fatalf("expected 3 merge requests, got %d", len(prs))
+	}
+
+	expected := map[int]string{1: "acme/one", 2: "acme/sub/two", 3: "jane/three"}
+	for id, repository := range expected {
+		if prs[id] == nil || prs[id].Repository != repository {
+			t.Errorf("expected merge request %d to be from '%s', got %+v", id, repository, prs[id])
+		}
+	}
+	if prs[2].WebLink != server.URL+"/acme/sub/two/merge_requests/2" {
+		t.Errorf("unexpected web link '%s'", prs[2].WebLink)
+	}
+}
+
+func TestGitLabProvider_FetchPaginated(t *testing.T) {
+	const pages = 3
+	const perPage = 20
+
+	server := newGitLabServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/approvals") {
+			fmt.Fprint(w, `{"approved_by": []}`)
+			return
+		}
+		if r.URL.EscapedPath() != "/api/v4/projects/acme%2Fone/merge_requests" {
+			t.Errorf("unexpected request to %s", r.URL.EscapedPath())
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("state") != "opened" {
+			t.Errorf("expected only opened merge requests to be requested")
+		}
+
+		page := 1
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		if page < pages {
+			w.Header().Set("X-Next-Page", fmt.Sprintf("%d", page+1))
+		}
+
+		// only the first merge request on each page has an assignee
+		fmt.Fprint(w, "[")
+		for i := 0; i < perPage; i++ {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			assignee := "null"
+			if i == 0 {
+				assignee = `{"username": "john"}`
+			}
+			fmt.Fprintf(w, `{"iid": %d, "title": "mr", "author": {"username": "jane"}, "assignee": %s, "updated_at": "2022-10-03T08:12:34Z"}`, (page-1)*perPage+i+1, assignee)
+		}
+		fmt.Fprint(w, "]")
+	})
+	defer server.Close()
+
+	provider := &GitLabProvider{
+		Token: "secret",
+		URL:   server.URL,
+		Repos: []string{"acme/one"},
+	}
+
+	prs := fetchAll(t, provider)
+	if len(prs) != pages*perPage {
+		t.Fatalf("expected %d merge requests, got %d", pages*perPage, len(prs))
+	}
+	for id := 1; id <= pages*perPage; id++ {
+		if prs[id] == nil {
+			t.Errorf("expected merge request %d to be fetched", id)
+		}
+	}
+	if prs[perPage+1].Assignee != "john" {
+		t.Errorf("expected merge request %d to be assigned to 'john', got '%s'", perPage+1, prs[perPage+1].Assignee)
+	}
+	if prs[perPage+2].Assignee != "" {
+		t.Errorf("expected merge request %d to be unassigned, got '%s'", perPage+2, prs[perPage+2].Assignee)
+	}
+}
+
+func TestGitLabProvider_FetchReviewState(t *testing.T) {
+	server := newGitLabServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/acme%2Fone/merge_requests":
+			fmt.Fprint(w, `[
+				{"iid": 1, "title": "approved", "author": {"username": "jane"}, "updated_at": "2022-10-03T08:12:34Z", "blocking_discussions_resolved": true},
+				{"iid": 2, "title": "unresolved", "author": {"username": "jane"}, "updated_at": "2022-10-03T08:12:34Z", "blocking_discussions_resolved": false},
+				{"iid": 3, "title": "draft", "author": {"username": "jane"}, "updated_at": "2022-10-03T08:12:34Z", "blocking_discussions_resolved": true, "draft": true},
+				{"iid": 4, "title": "Draft: wip", "author": {"username": "jane"}, "updated_at": "2022-10-03T08:12:34Z", "blocking_discussions_resolved": true, "work_in_progress": true},
+				{"iid": 5, "title": "partially approved", "author": {"username": "jane"}, "updated_at": "2022-10-03T08:12:34Z", "blocking_discussions_resolved": true}
+			]`)
+		case "/api/v4/projects/acme%2Fone/merge_requests/1/approvals", "/api/v4/projects/acme%2Fone/merge_requests/2/approvals":
+			fmt.Fprint(w, `{"approved": true, "approvals_required": 1, "approvals_left": 0, "approved_by": [{"user": {"username": "john"}}]}`)
+		case "/api/v4/projects/acme%2Fone/merge_requests/5/approvals":
+			fmt.Fprint(w, `{"approved": false, "approvals_required": 2, "approvals_left": 1, "approved_by": [{"user": {"username": "john"}}],
+				"approver_groups": [{"group": {"full_path": "acme/everyone"}}],
+				"approval_rules_left": [
+					{"name": "backend", "groups": [{"full_path": "acme/backend"}]},
+					{"name": "database", "groups": [{"full_path": "acme/backend"}]}
+				]}`)
+		case "/api/v4/projects/acme%2Fone/merge_requests/3/approvals", "/api/v4/projects/acme%2Fone/merge_requests/4/approvals":
+			fmt.Fprint(w, `{"approved": true, "approved_by": []}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.EscapedPath())
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	provider := &GitLabProvider{
+		Token: "secret",
+		URL:   server.URL,
+		Repos: []string{"acme/one"},
+	}
+
+	prs := fetchAll(t, provider)
+	if len(prs) != 5 {
+		t.Fatalf("expected 5 merge requests, got %d", len(prs))
+	}
+
+	tests := []struct {
+		id              int
+		approved        bool
+		requiresChanges bool
+		draft           bool
+	}{
+		{id: 1, approved: true},
+		{id: 2, requiresChanges: true},
+		{id: 3, draft: true},
+		{id: 4, draft: true},
+		// one of the two required approvals
+		{id: 5},
+	}
+	for _, test := range tests {
+		pr := prs[test.id]
+		if pr.Approved != test.approved || pr.RequiresChanges != test.requiresChanges || pr.Draft != test.draft {
+			t.Errorf("merge request %d: expected approved %t, requires changes %t and draft %t, got %+v", test.id, test.approved, test.requiresChanges, test.draft, pr)
+		}
+	}
+
+	if pr := prs[1]; pr.Approvals != 1 || pr.Reviews[0].Reviewer != "john" || pr.Reviews[0].State != ReviewApproved {
+		t.Errorf("expected merge request 1 to be approved by john, got %+v", pr)
+	}
+	if pr := prs[5]; pr.Approvals != 1 || len(pr.ReviewerTeams) != 1 || pr.ReviewerTeams[0] != "acme/backend" {
+		t.Errorf("expected merge request 5 to have 1 approval and wait on acme/backend, got %+v", pr)
+	}
+	if pr := prs[3]; pr.Approvals != 0 || len(pr.ReviewerTeams) != 0 {
+		t.Errorf("expected merge request 3 to have no approvals and no groups, got %+v", pr)
+	}
+}
+
+// newGitLabServer returns a fake GitLab server that checks the private token. The request the client
+// uses to probe for rate limits is answered without calling the handler.
+func newGitLabServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v4/" {
+			return
+		}
+		if r.Header.Get("Private-Token") != "secret" {
+			t.Errorf("expected private token, got '%s'", r.Header.Get("Private-Token"))
+		}
+		handler(w, r)
+	}))
+}
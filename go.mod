@@ -3,7 +3,6 @@ module github.com/stojg/purr
 go 1.18
 
 require (
-	github.com/bluele/slack v0.0.0-20180528010058-b4b4d354a079
 	github.com/dustin/go-humanize v1.0.0
 	github.com/google/go-github/v47 v47.1.0
 	github.com/xanzy/go-gitlab v0.73.1
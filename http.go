@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// requestTimeout is the longest time a single API request may take, including reading the response
+// body. It's set with the -request-timeout flag and disabled when zero.
+var requestTimeout = 30 * time.Second
+
+// defaultTransport is used by all API clients instead of http.DefaultTransport
+var defaultTransport http.RoundTripper = &timeoutTransport{Base: http.DefaultTransport}
+
+// defaultClient is used for requests that don't need authentication
+var defaultClient = &http.Client{Transport: defaultTransport}
+
+// timeoutTransport cancels requests that take longer than the request timeout
+type timeoutTransport struct {
+	Base http.RoundTripper
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if requestTimeout <= 0 {
+		return t.Base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+	resp, err := t.Base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the request can only be cancelled once the body has been read
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelReadCloser cancels a context when it's closed
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
+
+// newTokenClient returns a client that authenticates requests with OAuth 2.0 bearer tokens from the
+// token source
+func newTokenClient(ts oauth2.TokenSource) *http.Client {
+	return &http.Client{Transport: &oauth2.Transport{Source: oauth2.ReuseTokenSource(nil, ts), Base: defaultTransport}}
+}
+
+// basicAuthTransport adds HTTP basic authentication to every request
+type basicAuthTransport struct {
+	Username string
+	Password string
+}
+
+func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.Username, t.Password)
+	return defaultTransport.RoundTrip(req)
+}
+
+// getJSON sends a GET request to url and decodes the JSON response body into v
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(client, req, v)
+}
+
+// postJSON sends the payload encoded as JSON in a POST request to url and decodes the JSON response
+// body into v, unless v is nil
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, v interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doJSON(client, req, v)
+}
+
+// doJSON sends the request and decodes the JSON response body into v, unless v is nil. JSON is
+// accepted unless the request already has an Accept header. An error is returned for any non 2xx
+// response.
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s %s", req.Method, req.URL, resp.Status, body)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	defer func(timeout time.Duration) { requestTimeout = timeout }(requestTimeout)
+	requestTimeout = 50 * time.Millisecond
+
+	blocked := make(chan struct{})
+	defer close(blocked)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-blocked:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	if err := getJSON(context.Background(), defaultClient, server.URL, nil); err == nil {
+		t.Errorf("expected the request to time out")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("expected the request to be cancelled after the request timeout, took %s", time.Since(start))
+	}
+}
@@ -2,15 +2,15 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"os"
-	"strings"
+	"os/signal"
+	"sort"
 	"sync"
+	"syscall"
 	"time"
-
-	"github.com/bluele/slack"
-	"github.com/dustin/go-humanize"
 )
 
 const (
@@ -24,12 +24,17 @@ var (
 	configFile string
 	debug      bool
 	cliOutput  bool
+	timeout    time.Duration
+	failOnErrs bool
 )
 
 func main() {
 	flag.StringVar(&configFile, "config", "", "Read config from FILE")
 	flag.BoolVar(&debug, "d", false, "run in debug mode")
-	flag.BoolVar(&cliOutput, "o", false, "output to CLI rather than slack")
+	flag.BoolVar(&cliOutput, "o", false, "output to CLI rather than the configured outputs")
+	flag.DurationVar(&timeout, "timeout", 5*time.Minute, "give up fetching pull requests after this long, 0 to disable")
+	flag.DurationVar(&requestTimeout, "request-timeout", requestTimeout, "give up a single API request after this long, 0 to disable")
+	flag.BoolVar(&failOnErrs, "fail-on-errors", false, "exit with a non-zero status if any repository or pull request could not be fetched")
 
 	flag.Usage = func() {
 		fmt.Fprint(os.Stderr, fmt.Sprintf(BANNER, VERSION))
@@ -55,36 +60,77 @@ func main() {
 		usageAndExit(buf.String(), 1)
 	}
 
-	// these function will return channels that will emit a list of pull requests
-	// on channels and close the channel when they are done
-	gitHubPRs := trawlGitHub(conf, logger)
-	gitLabPRs := trawlGitLab(conf, logger)
+	// stop all requests when purr is interrupted, and stop fetching when it has taken too long. The
+	// timeout doesn't apply to sending the report, so a slow fetch can't leave it without time.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fetchCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// errors for repositories and pull requests that could not be fetched are collected and added to
+	// the message
+	errs := NewFetchErrors(logger)
+
+	// each provider will return a channel that will emit a list of pull requests and close the
+	// channel when they are done
+	var channels []<-chan *PullRequest
+	for _, provider := range conf.Providers() {
+		prs, err := provider.Fetch(fetchCtx, logger, errs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not fetch pull requests from %s: %v\n", provider.Name(), err)
+			os.Exit(1)
+		}
+		channels = append(channels, prs)
+	}
 
 	// Merge the in channels into of channel and close it when the inputs are done
-	prs := merge(gitHubPRs, gitLabPRs)
+	prs := merge(fetchCtx, channels...)
 
 	// filter out pull requests that we don't want to send
-	filteredPRs := filter(conf.Filters, prs, logger)
+	filteredPRs := filter(fetchCtx, conf.Filters, prs, logger)
 
-	// format takes a channel of pull requests and returns a message that groups
-	// pull request into repos and formats them into a slack friendly format
-	message := format(conf.Filters, filteredPRs)
+	// format takes a channel of pull requests and returns a report that groups
+	// pull request into repos
+	report, err := format(fetchCtx, conf.Filters, filteredPRs, errs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not fetch pull requests: %v\n", err)
+		os.Exit(1)
+	}
 
-	if message.String() == "" {
+	if report.String() == "" {
 		logger.Debugf("No PRs found\n")
-	} else if cliOutput {
-		fmt.Print(message)
 	} else {
-		err := postToSlack(conf, message)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Could not send to slack: %v\n", err)
+		notifiers := conf.Notifiers()
+		if cliOutput {
+			notifiers = []Notifier{&StdoutNotifier{}}
+		}
+
+		// one output failing doesn't stop the report from being sent to the other outputs
+		failed := false
+		for _, notifier := range notifiers {
+			if err := notifier.Notify(ctx, report); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not send to %s: %v\n", notifier.Name(), err)
+				failed = true
+			}
+		}
+		if failed {
 			os.Exit(1)
 		}
 	}
+
+	if failOnErrs && len(errs.Errors()) > 0 {
+		fmt.Fprintf(os.Stderr, "%d repositories or pull requests could not be fetched\n", len(errs.Errors()))
+		os.Exit(1)
+	}
 }
 
-// merge merges several channels into one output channel (fan-in)
-func merge(channels ...<-chan *PullRequest) <-chan *PullRequest {
+// merge merges several channels into one output channel (fan-in), it stops when the context is
+// cancelled
+func merge(ctx context.Context, channels ...<-chan *PullRequest) <-chan *PullRequest {
 	out := make(chan *PullRequest)
 
 	var wg sync.WaitGroup
@@ -93,10 +139,22 @@ func merge(channels ...<-chan *PullRequest) <-chan *PullRequest {
 	// merges all in channels into an out channel
 	for _, c := range channels {
 		go func(prs <-chan *PullRequest) {
-			for pr := range prs {
-				out <- pr
+			defer wg.Done()
+			for {
+				select {
+				case pr, ok := <-prs:
+					if !ok {
+						return
+					}
+					select {
+					case out <- pr:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
 			}
-			wg.Done()
 		}(c)
 	}
 
@@ -111,93 +169,63 @@ func merge(channels ...<-chan *PullRequest) <-chan *PullRequest {
 
 // filter removes pull requests that should not show up in the final message, this could
 // include PRs marked as Work in Progress or where users are not in the whitelist
-func filter(filters *Filters, in <-chan *PullRequest, log Logger) chan *PullRequest {
+func filter(ctx context.Context, filters *Filters, in <-chan *PullRequest, log Logger) chan *PullRequest {
 	out := make(chan *PullRequest)
 
 	go func() {
+		defer close(out)
 		for pr := range in {
-			if filters.Filter(pr) {
-				out <- pr
-			} else {
+			if !filters.Filter(pr) {
 				log.Debugf("filtered PR '%s' (%s) \n", pr.Title, pr.WebLink)
+				continue
+			}
+			select {
+			case out <- pr:
+			case <-ctx.Done():
+				return
 			}
 		}
-		close(out)
 	}()
 	return out
 }
 
-// format converts all pull requests into a message that is grouped by repo formatted for slack
-func format(filters *Filters, prs <-chan *PullRequest) fmt.Stringer {
-	var numPRs int
-	var oldest *PullRequest
-	repositories := make(map[string][]*PullRequest)
-	lastUpdated := time.Now()
+// format groups all pull requests by their repository into a report, together with the
+// repositories and pull requests that could not be fetched. An error is returned if the context is cancelled before
+// all pull requests have been received.
+func format(ctx context.Context, filters *Filters, prs <-chan *PullRequest, errs *FetchErrors) (*Report, error) {
+	repositories := make(map[string]*RepositoryReport)
 
 	// loop through all PRs, will stop when the channel is closed
 	for pr := range prs {
-		// update the oldest pull request
-		if pr.Updated.Before(lastUpdated) {
-			oldest = pr
-			lastUpdated = pr.Updated
-		}
-		// update the total count of PRs
-		numPRs++
 		// group PRs with their repository
 		if _, ok := repositories[pr.Repository]; !ok {
-			repositories[pr.Repository] = make([]*PullRequest, 0)
-		}
-		repositories[pr.Repository] = append(repositories[pr.Repository], pr)
-	}
-
-	// create the slack message in "slack" format
-	buf := &bytes.Buffer{}
-	for repo, prs := range repositories {
-		fmt.Fprintf(buf, "*%s*\n", repo)
-		for i := range prs {
-			fmt.Fprintf(buf, "%s\n", prs[i])
+			repositories[pr.Repository] = &RepositoryReport{Name: pr.Repository}
 		}
-		fmt.Fprint(buf, "\n")
+		repositories[pr.Repository].PullRequests = append(repositories[pr.Repository].PullRequests, pr)
 	}
 
-	// summary
-	if numPRs > 0 {
-		fmt.Fprintf(buf, "\nThere are currently %d open pull requests", numPRs)
-		fmt.Fprintf(buf, " and the oldest (<%s|PR #%d>) was updated %s\n", oldest.WebLink, oldest.ID, humanize.Time(oldest.Updated))
+	// the channels are closed early when the context is cancelled, so the pull requests are incomplete
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
-	fmt.Fprintf(buf, "%d pull request(s) filtered from these results\n", filters.NumFiltered())
-	return buf
-}
-
-// postToSlack will post the message to Slack. It will divide the message into smaller message if
-// it's more than 30 lines long due to a max message size limitation enforced by the Slack API
-func postToSlack(conf *Config, message fmt.Stringer) error {
-
-	const maxLines = 30
 
-	client := slack.New(conf.SlackToken)
-	opt := &slack.ChatPostMessageOpt{
-		AsUser:    false,
-		Username:  "purr",
-		IconEmoji: ":purr:",
+	// all providers are done when the pull request channel has been closed, so no more errors will
+	// be added
+	report := &Report{
+		Errors:      errs.Errors(),
+		NumFiltered: filters.NumFiltered(),
 	}
-
-	// Don't send too large messages, send a new message per maxLines new lines
-	lines := strings.Split(message.String(), "\n")
-	lineBuffer := make([]string, maxLines)
-	for i := range lines {
-		lineBuffer = append(lineBuffer, lines[i])
-		if len(lineBuffer) == cap(lineBuffer) || i+1 == len(lines) {
-			msg := strings.Join(lineBuffer, "\n")
-			if msg != "" {
-				if err := client.ChatPostMessage(conf.SlackChannel, msg, opt); err != nil {
-					return err
-				}
-			}
-			lineBuffer = make([]string, cap(lineBuffer))
-		}
+	for _, repo := range repositories {
+		// the most recently updated pull requests first, the same order as the providers return them
+		sort.SliceStable(repo.PullRequests, func(i, j int) bool {
+			return repo.PullRequests[i].Updated.After(repo.PullRequests[j].Updated)
+		})
+		report.Repositories = append(report.Repositories, repo)
 	}
-	return nil
+	sort.Slice(report.Repositories, func(i, j int) bool {
+		return report.Repositories[i].Name < report.Repositories[j].Name
+	})
+	return report, nil
 }
 
 func usageAndExit(message string, exitCode int) {
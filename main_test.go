@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// a provider that never finishes
+	in := make(chan *PullRequest)
+	go func() {
+		in <- &PullRequest{ID: 1, Repository: "acme/one", Updated: time.Now()}
+		cancel()
+	}()
+
+	filters := &Filters{}
+	if _, err := format(ctx, filters, filter(ctx, filters, merge(ctx, in), NewStdOutLogger(false)), NewFetchErrors(NewStdOutLogger(false))); err != context.Canceled {
+		t.Errorf("expected the context to be cancelled, got %v", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	in := make(chan *PullRequest, 2)
+	in <- &PullRequest{ID: 1, Repository: "acme/one", Updated: time.Now()}
+	in <- &PullRequest{ID: 2, Repository: "acme/one", Updated: time.Now()}
+	close(in)
+
+	ctx := context.Background()
+	filters := &Filters{}
+	message, err := format(ctx, filters, filter(ctx, filters, merge(ctx, in), NewStdOutLogger(false)), NewFetchErrors(NewStdOutLogger(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.String() == "" {
+		t.Errorf("expected a message")
+	}
+}
+
+func TestFormat_FetchErrors(t *testing.T) {
+	in := make(chan *PullRequest)
+	close(in)
+
+	errs := NewFetchErrors(NewStdOutLogger(false))
+	errs.Add("GitLab", "acme/two", fmt.Errorf("401 Unauthorized"))
+	errs.Add("GitHub", "acme/one", fmt.Errorf("<html>"))
+
+	ctx := context.Background()
+	filters := &Filters{}
+	message, err := format(ctx, filters, in, errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "*Could not be fetched*\n • GitHub acme/one: &lt;html&gt;\n • GitLab acme/two: 401 Unauthorized\n"
+	if !strings.Contains(message.String(), expected) {
+		t.Errorf("Expected message to contain\n%s\ngot\n%s", expected, message)
+	}
+}
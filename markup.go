@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// markup is how a chat platform formats links and emphasis and which characters have to be escaped,
+// so that pull requests can be rendered with the rules of each platform
+type markup struct {
+	// escape escapes the characters of plain text that would otherwise be formatted
+	escape func(text string) string
+	// link returns a link to the url, the text is already escaped
+	link func(url, text string) string
+	// bold and italic are the delimiters that are put around emphasised text
+	bold   string
+	italic string
+}
+
+var (
+	// slackMarkup is the mrkdwn format of Slack, see https://api.slack.com/reference/surfaces/formatting
+	slackMarkup = &markup{
+		escape: escapeSlack,
+		link:   func(url, text string) string { return fmt.Sprintf("<%s|%s>", url, text) },
+		bold:   "*",
+		italic: "_",
+	}
+
+	// markdownMarkup is CommonMark with the HTML characters escaped as entities, as it may be
+	// rendered as HTML
+	markdownMarkup = &markup{
+		escape: strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, "`", "\\`", "<", "&lt;", ">", "&gt;").Replace,
+		link:   markdownLink,
+		bold:   "**",
+		italic: "_",
+	}
+
+	// discordMarkup is the markdown of Discord, which also formats strike-through, spoilers and
+	// quotes, see https://support.discord.com/hc/en-us/articles/210298617
+	discordMarkup = &markup{
+		escape: strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `~`, `\~`, "`", "\\`", `|`, `\|`, `>`, `\>`, `[`, `\[`, `]`, `\]`, `#`, `\#`).Replace,
+		link:   markdownLink,
+		bold:   "**",
+		italic: "_",
+	}
+
+	// mattermostMarkup is the markdown of Mattermost, which doesn't render HTML so angle brackets
+	// are escaped with a backslash instead of as entities
+	mattermostMarkup = &markup{
+		escape: strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `~`, `\~`, "`", "\\`", `[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`).Replace,
+		link:   markdownLink,
+		bold:   "**",
+		italic: "_",
+	}
+
+	// rocketChatMarkup is the markdown of Rocket.Chat, which uses single asterisks for bold text like
+	// Slack but markdown links
+	rocketChatMarkup = &markup{
+		escape: strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `~`, `\~`, "`", "\\`", `[`, `\[`, `]`, `\]`).Replace,
+		link:   markdownLink,
+		bold:   "*",
+		italic: "_",
+	}
+
+	// plainTextMarkup looks like Markdown but is never rendered, so nothing is escaped, e.g. for the
+	// plain text part of an email
+	plainTextMarkup = &markup{
+		escape: func(text string) string { return text },
+		link:   markdownLink,
+		bold:   "**",
+		italic: "_",
+	}
+)
+
+func markdownLink(url, text string) string {
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+// pullRequest renders a pull request as a single line with a link, the title, author, approval,
+// assignee, the reviewers it's waiting on and age
+func (m *markup) pullRequest(pr *PullRequest) string {
+	line := fmt.Sprintf("%s %s - %s%s%s", m.link(pr.WebLink, fmt.Sprintf("#%d", pr.ID)), m.escape(pr.Title), m.italic, m.escape(pr.Author), m.italic)
+	if pr.Approved {
+		line += fmt.Sprintf(", %sAPPROVED%s", m.bold, m.bold)
+	}
+	if pr.Assignee != "" {
+		line += fmt.Sprintf(", assigned to %s%s%s", m.italic, m.escape(pr.Assignee), m.italic)
+	}
+	if waiting := pr.WaitingOn(); len(waiting) > 0 {
+		var reviewers []string
+		for _, reviewer := range waiting {
+			reviewers = append(reviewers, "@"+m.escape(reviewer))
+		}
+		line += fmt.Sprintf(", waiting on %s", strings.Join(reviewers, ", "))
+	}
+	return line + fmt.Sprintf(" - updated %s", humanize.Time(pr.Updated))
+}
+
+// summary returns the number of open pull requests, when the oldest was updated and how many were
+// filtered as separate lines
+func (m *markup) summary(report *Report) []string {
+	var lines []string
+	if oldest := report.Oldest(); oldest != nil {
+		lines = append(lines, fmt.Sprintf("There are currently %d open pull requests and the oldest (%s) was updated %s",
+			report.NumPullRequests(), m.link(oldest.WebLink, fmt.Sprintf("PR #%d", oldest.ID)), humanize.Time(oldest.Updated)))
+	}
+	return append(lines, fmt.Sprintf("%d pull request(s) filtered from these results", report.NumFiltered))
+}
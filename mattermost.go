@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// mattermostMaxSize is the number of characters of the attachments per message, Mattermost accepts
+// posts of up to 16383 characters and Rocket.Chat messages of up to 5000 characters by default
+const mattermostMaxSize = 4000
+
+func init() {
+	RegisterNotifier("mattermost", newMattermostNotifier)
+	RegisterNotifier("rocketchat", newRocketChatNotifier)
+}
+
+// MattermostNotifier posts the report to a Mattermost or Rocket.Chat incoming webhook, which both
+// accept Slack compatible attachments but format the text with their own markdown
+type MattermostNotifier struct {
+	WebhookURL string
+	// Channel overrides the channel of the webhook if it is set
+	Channel string
+
+	name   string
+	markup *markup
+}
+
+func newMattermostNotifier(output *Output) Notifier {
+	return &MattermostNotifier{WebhookURL: output.WebhookURL, Channel: output.Channel, name: "Mattermost", markup: mattermostMarkup}
+}
+
+func newRocketChatNotifier(output *Output) Notifier {
+	return &MattermostNotifier{WebhookURL: output.WebhookURL, Channel: output.Channel, name: "Rocket.Chat", markup: rocketChatMarkup}
+}
+
+// Name returns the name of the output
+func (n *MattermostNotifier) Name() string {
+	return n.name
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (n *MattermostNotifier) Validate() []error {
+	var errors []error
+	if _, err := url.ParseRequestURI(n.WebhookURL); err != nil {
+		errors = append(errors, fmt.Errorf("%s is not a valid %s webhook URL: %v", n.WebhookURL, n.name, err))
+	}
+	return errors
+}
+
+// attachmentMessage is a message with Slack compatible attachments, see
+// https://developers.mattermost.com/integrate/reference/message-attachments/
+type attachmentMessage struct {
+	Channel     string        `json:"channel,omitempty"`
+	Text        string        `json:"text,omitempty"`
+	Attachments []*attachment `json:"attachments"`
+}
+
+type attachment struct {
+	Fallback string `json:"fallback"`
+	Color    string `json:"color"`
+	Title    string `json:"title,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Notify posts the report to the webhook, split into several messages if it's too large
+func (n *MattermostNotifier) Notify(ctx context.Context, report *Report) error {
+	for _, message := range n.messages(report) {
+		if err := postJSON(ctx, defaultClient, n.WebhookURL, message, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messages renders the report as messages with an attachment for each repository
+func (n *MattermostNotifier) messages(report *Report) []*attachmentMessage {
+	var messages []*attachmentMessage
+	for i, sections := range splitChatSections(chatSections(report, n.markup), 0, mattermostMaxSize) {
+		message := &attachmentMessage{Channel: n.Channel}
+		if i == 0 {
+			message.Text = fmt.Sprintf("%sOpen pull requests%s", n.markup.bold, n.markup.bold)
+		}
+		for _, section := range sections {
+			message.Attachments = append(message.Attachments, &attachment{
+				Fallback: section.Text(),
+				Color:    section.HexColor(),
+				Title:    section.Title,
+				Text:     section.Text(),
+			})
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
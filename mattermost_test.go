@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMattermostNotifier_Notify(t *testing.T) {
+	tests := []struct {
+		outputType string
+		heading    string
+		expected   string
+	}{
+		{"mattermost", "**Open pull requests**", `• [#1](https://github.com/acme/repo0/pull/1) fix \<script\> the\_things - _jane_, **APPROVED** - updated`},
+		{"rocketchat", "*Open pull requests*", `• [#1](https://github.com/acme/repo0/pull/1) fix <script> the\_things - _jane_, *APPROVED* - updated`},
+	}
+	for _, test := range tests {
+		t.Run(test.outputType, func(t *testing.T) {
+			var messages []*attachmentMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				message := &attachmentMessage{}
+				if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+					t.Error(err)
+				}
+				messages = append(messages, message)
+			}))
+			defer server.Close()
+
+			report := newSlackReport(1)
+			report.Repositories[0].PullRequests[0].Title = "fix <script> the_things"
+			report.Repositories[0].PullRequests[0].Approved = true
+			notifier := notifierFactories[test.outputType](&Output{Type: test.outputType, WebhookURL: server.URL, Channel: "town-square"})
+			if errs := notifier.Validate(); len(errs) != 0 {
+				t.Fatalf("Did not expect validation errors, got %v", errs)
+			}
+			if err := notifier.Notify(context.Background(), report); err != nil {
+				t.Fatal(err)
+			}
+			if len(messages) != 1 {
+				t.Fatalf("Expected 1 message, got %d", len(messages))
+			}
+			message := messages[0]
+			if message.Text != test.heading || message.Channel != "town-square" {
+				t.Errorf("Expected heading '%s' in 'town-square', got '%s' in '%s'", test.heading, message.Text, message.Channel)
+			}
+			if len(message.Attachments) != 2 {
+				t.Fatalf("Expected an attachment for the repository and the summary, got %d", len(message.Attachments))
+			}
+			if message.Attachments[0].Title != "acme/repo0" || message.Attachments[0].Color != "#439fe0" {
+				t.Errorf("Expected the first attachment to be 'acme/repo0', got %+v", message.Attachments[0])
+			}
+			if !strings.HasPrefix(message.Attachments[0].Text, test.expected) {
+				t.Errorf("Expected the attachment to start with\n%s\ngot\n%s", test.expected, message.Attachments[0].Text)
+			}
+		})
+	}
+}
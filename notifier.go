@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Notifier sends the report to an output, e.g. Slack or a file
+type Notifier interface {
+	// Name returns a human readable name of the output
+	Name() string
+	// Validate returns a list of errors for any invalid output configuration
+	Validate() []error
+	// Notify sends the report
+	Notify(ctx context.Context, report *Report) error
+}
+
+// NotifierFactory creates the notifier for an entry in `outputs`
+type NotifierFactory func(output *Output) Notifier
+
+// notifierFactories is the registry of all known notifiers keyed by their output type
+var notifierFactories = make(map[string]NotifierFactory)
+
+// RegisterNotifier adds a notifier with the output type that selects it in `outputs` to the
+// registry, it's typically called from an init function in the file that implements the notifier
+func RegisterNotifier(outputType string, factory NotifierFactory) {
+	notifierFactories[outputType] = factory
+}
+
+// Output is the configuration of an entry in `outputs`. Type selects the notifier and only the
+// settings of that notifier are used.
+type Output struct {
+	Type       string `json:"type"`
+	Format     string `json:"format,omitempty"`
+	Token      string `json:"token,omitempty"`
+	Channel    string `json:"channel,omitempty"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Path       string `json:"path,omitempty"`
+	// the settings of Slack direct messages and mentions
+	DirectMessages bool              `json:"direct_messages,omitempty"`
+	Users          map[string]string `json:"users,omitempty"`
+	Mentions       string            `json:"mentions,omitempty"`
+	// the settings of the email output
+	Host     string   `json:"host,omitempty"`
+	Port     int      `json:"port,omitempty"`
+	StartTLS bool     `json:"starttls,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+	Subject  string   `json:"subject,omitempty"`
+}
+
+// Notifiers returns a notifier for each of the configured outputs in order. The top level Slack and
+// Microsoft Teams settings are added before the outputs.
+func (c *Config) Notifiers() []Notifier {
+	var outputs []*Output
+	if c.SlackToken != "" || c.SlackChannel != "" || c.SlackWebhookURL != "" {
+		outputs = append(outputs, &Output{
+			Type:           "slack",
+			Token:          c.SlackToken,
+			Channel:        c.SlackChannel,
+			WebhookURL:     c.SlackWebhookURL,
+			DirectMessages: c.SlackDirectMessages,
+			Users:          c.SlackUsers,
+			Mentions:       c.SlackMentions,
+		})
+	}
+	if c.TeamsWebhookURL != "" {
+		outputs = append(outputs, &Output{Type: "teams", WebhookURL: c.TeamsWebhookURL})
+	}
+	outputs = append(outputs, c.Outputs...)
+
+	var notifiers []Notifier
+	for _, output := range outputs {
+		// Slack outputs share the top level users unless they have their own
+		if output.Type == "slack" && output.Users == nil {
+			shared := *output
+			shared.Users = c.SlackUsers
+			output = &shared
+		}
+		if factory, ok := notifierFactories[output.Type]; ok {
+			notifiers = append(notifiers, factory(output))
+		}
+	}
+	return notifiers
+}
+
+// validateOutputs returns an error for every output with an unknown type and the errors of all
+// configured notifiers
+func (c *Config) validateOutputs() []error {
+	var errors []error
+	for _, output := range c.Outputs {
+		if _, ok := notifierFactories[output.Type]; !ok {
+			errors = append(errors, fmt.Errorf("%s is not a valid output type", output.Type))
+		}
+	}
+	for _, notifier := range c.Notifiers() {
+		errors = append(errors, notifier.Validate()...)
+	}
+	return errors
+}
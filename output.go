@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+func init() {
+	RegisterNotifier("stdout", newStdoutNotifier)
+	RegisterNotifier("file", newFileNotifier)
+	RegisterNotifier("webhook", newWebhookNotifier)
+}
+
+// StdoutNotifier prints the report
+type StdoutNotifier struct {
+	Format string
+	out    io.Writer
+}
+
+func newStdoutNotifier(output *Output) Notifier {
+	return &StdoutNotifier{Format: output.Format}
+}
+
+// Name returns the name of the output
+func (n *StdoutNotifier) Name() string {
+	return "stdout"
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (n *StdoutNotifier) Validate() []error {
+	var errors []error
+	if _, err := newFormatter(n.Format); err != nil {
+		errors = append(errors, err)
+	}
+	return errors
+}
+
+// Notify prints the formatted report
+func (n *StdoutNotifier) Notify(ctx context.Context, report *Report) error {
+	formatter, err := newFormatter(n.Format)
+	if err != nil {
+		return err
+	}
+	data, err := formatter.Format(report)
+	if err != nil {
+		return err
+	}
+	out := n.out
+	if out == nil {
+		out = os.Stdout
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// FileNotifier writes the report to a file, e.g. to publish it as a build artifact
+type FileNotifier struct {
+	Path   string
+	Format string
+}
+
+func newFileNotifier(output *Output) Notifier {
+	return &FileNotifier{Path: output.Path, Format: output.Format}
+}
+
+// Name returns the name of the output
+func (n *FileNotifier) Name() string {
+	return fmt.Sprintf("file (%s)", n.Path)
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (n *FileNotifier) Validate() []error {
+	var errors []error
+	if n.Path == "" {
+		errors = append(errors, fmt.Errorf("File output path cannot be empty"))
+	}
+	if _, err := newFormatter(n.Format); err != nil {
+		errors = append(errors, err)
+	}
+	return errors
+}
+
+// Notify writes the formatted report to the file, replacing any previous report
+func (n *FileNotifier) Notify(ctx context.Context, report *Report) error {
+	formatter, err := newFormatter(n.Format)
+	if err != nil {
+		return err
+	}
+	data, err := formatter.Format(report)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(n.Path, data, 0644)
+}
+
+// WebhookNotifier posts the report to a URL
+type WebhookNotifier struct {
+	URL    string
+	Format string
+}
+
+func newWebhookNotifier(output *Output) Notifier {
+	return &WebhookNotifier{URL: output.URL, Format: output.Format}
+}
+
+// Name returns the name of the output
+func (n *WebhookNotifier) Name() string {
+	return fmt.Sprintf("webhook (%s)", n.URL)
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (n *WebhookNotifier) Validate() []error {
+	var errors []error
+	if _, err := url.ParseRequestURI(n.URL); err != nil {
+		errors = append(errors, fmt.Errorf("%s is not a valid webhook URL: %v", n.URL, err))
+	}
+	if _, err := newFormatter(n.Format); err != nil {
+		errors = append(errors, err)
+	}
+	return errors
+}
+
+// Notify posts the formatted report to the URL
+func (n *WebhookNotifier) Notify(ctx context.Context, report *Report) error {
+	formatter, err := newFormatter(n.Format)
+	if err != nil {
+		return err
+	}
+	data, err := formatter.Format(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", formatter.ContentType())
+	return doJSON(defaultClient, req, nil)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdoutNotifier_Notify(t *testing.T) {
+	buf := &bytes.Buffer{}
+	notifier := &StdoutNotifier{Format: "markdown", out: buf}
+	if err := notifier.Notify(context.Background(), newSlackReport(1)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "## acme/repo0\n\n- [#1](https://github.com/acme/repo0/pull/1) fix &lt;script&gt; - _jane_ - updated"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected the output to contain\n%s\ngot\n%s", expected, buf)
+	}
+}
+
+func TestFileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "purr.json")
+	notifier := &FileNotifier{Path: path, Format: "json"}
+	if err := notifier.Notify(context.Background(), newSlackReport(2, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := struct {
+		Repositories []*RepositoryReport `json:"repositories"`
+	}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Repositories) != 2 || len(result.Repositories[0].PullRequests) != 2 {
+		t.Errorf("Expected 2 repositories with 2 and 1 pull requests, got %s", data)
+	}
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "text/plain; charset=utf-8" {
+			t.Errorf("Expected a text body, got '%s'", r.Header.Get("Content-Type"))
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	notifier := &WebhookNotifier{URL: server.URL}
+	report := newSlackReport(1)
+	if err := notifier.Notify(context.Background(), report); err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != report.String() {
+		t.Errorf("Expected the report to be posted, got\n%s", body)
+	}
+}
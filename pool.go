@@ -0,0 +1,35 @@
+package main
+
+import "sync"
+
+// workerPool runs functions in goroutines, but never more than a fixed number at the same time
+type workerPool struct {
+	workers chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+	return &workerPool{
+		workers: make(chan struct{}, size),
+	}
+}
+
+// Go runs f as soon as a worker is available. It never blocks, so a function that is running in
+// the pool can queue more functions without dead locking the pool.
+func (p *workerPool) Go(f func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.workers <- struct{}{}
+		defer func() { <-p.workers }()
+		f()
+	}()
+}
+
+// Wait blocks until all queued functions have returned
+func (p *workerPool) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Provider is a source of pull requests, e.g. GitHub or GitLab
+type Provider interface {
+	// Name returns a human readable name of the provider
+	Name() string
+	// Validate returns a list of errors for any invalid provider configuration
+	Validate() []error
+	// Fetch returns a channel that will emit the open pull requests and close when all of them
+	// has been fetched. An error is returned if the provider could not start fetching, errors for
+	// single repositories are added to errs.
+	Fetch(ctx context.Context, log Logger, errs *FetchErrors) (<-chan *PullRequest, error)
+}
+
+// ProviderFactory creates the providers from the configuration, typically one or none if the
+// provider has not been configured
+type ProviderFactory func(conf *Config) []Provider
+
+// providerFactories is the registry of all known providers
+var providerFactories []ProviderFactory
+
+// RegisterProvider adds a provider to the registry, it's typically called from an init function
+// in the file that implements the provider
+func RegisterProvider(factory ProviderFactory) {
+	providerFactories = append(providerFactories, factory)
+}
+
+// Providers returns all registered providers that have been configured
+func (c *Config) Providers() []Provider {
+	var providers []Provider
+	for _, factory := range providerFactories {
+		providers = append(providers, factory(c)...)
+	}
+	return providers
+}
+
+// FetchError is an error from fetching the pull requests of a repository, the reviews of a pull
+// request, or the repositories of an organisation, group, user or search query
+type FetchError struct {
+	Provider string
+	// Key names what could not be fetched, e.g. "acme/one", "acme/one#12" or "acme (organisation)"
+	Key string
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Provider, e.Key, e.Err)
+}
+
+// FetchErrors collects the errors of all providers, so that repositories and pull requests that
+// could not be fetched are reported rather than silently left out. It's safe for concurrent use.
+type FetchErrors struct {
+	log    Logger
+	mu     sync.Mutex
+	errors []*FetchError
+}
+
+func NewFetchErrors(log Logger) *FetchErrors {
+	return &FetchErrors{log: log}
+}
+
+// Add records and logs an error for the key of a repository, pull request, organisation, group,
+// user or search query
+func (e *FetchErrors) Add(provider, key string, err error) {
+	e.log.Infof("%s %s could not be fetched: %s\n", provider, key, err)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors = append(e.errors, &FetchError{Provider: provider, Key: key, Err: err})
+}
+
+// Errors returns all errors sorted by provider and key
+func (e *FetchErrors) Errors() []*FetchError {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	errors := make([]*FetchError, len(e.errors))
+	copy(errors, e.errors)
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Provider != errors[j].Provider {
+			return errors[i].Provider < errors[j].Provider
+		}
+		return errors[i].Key < errors[j].Key
+	})
+	return errors
+}
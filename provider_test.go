@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// fetchAll returns all pull requests from the provider keyed by their ID
+func fetchAll(t *testing.T, provider Provider) map[int]*PullRequest {
+	in, err := provider.Fetch(context.Background(), NewStdOutLogger(false), NewFetchErrors(NewStdOutLogger(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prs := make(map[int]*PullRequest)
+	for pr := range in {
+		prs[pr.ID] = pr
+	}
+	return prs
+}
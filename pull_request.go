@@ -1,43 +1,74 @@
 package main
 
 import (
-	"fmt"
 	"strings"
 	"time"
+)
 
-	"github.com/dustin/go-humanize"
+// The states of a Review
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewCommented        = "commented"
 )
 
+// Review is the latest review of a reviewer
+type Review struct {
+	Reviewer string `json:"reviewer"`
+	State    string `json:"state"`
+}
+
 // PullRequest is a normalised version of PullRequest for the different providers
 type PullRequest struct {
-	ID              int
-	Author          string
-	Assignee        string
-	Updated         time.Time
-	WebLink         string
-	Title           string
-	Repository      string
-	RequiresChanges bool
-	Approved        bool
-	Draft           bool
+	ID              int       `json:"id"`
+	Author          string    `json:"author"`
+	Assignee        string    `json:"assignee"`
+	Updated         time.Time `json:"updated"`
+	WebLink         string    `json:"web_link"`
+	Title           string    `json:"title"`
+	Repository      string    `json:"repository"`
+	RequiresChanges bool      `json:"requires_changes"`
+	Approved        bool      `json:"approved"`
+	Draft           bool      `json:"draft"`
+	Labels          []string  `json:"labels"`
+	// Reviewers are the users whose review has been requested
+	Reviewers []string `json:"reviewers"`
+	// ReviewerTeams are the teams whose review has been requested, "org/team" on GitHub and the path
+	// of a group on GitLab
+	ReviewerTeams []string `json:"reviewer_teams"`
+	// Reviews are the latest reviews of each reviewer
+	Reviews []*Review `json:"reviews"`
+	// Approvals is the number of reviewers that have approved the pull request
+	Approvals int `json:"approvals"`
 }
 
+// String returns the pull request in the Slack mrkdwn format
 func (p *PullRequest) String() string {
+	return " • " + slackMarkup.pullRequest(p)
+}
 
-	// the following chars will to be escaped for slack
-	title := strings.Replace(p.Title, "&", "&amp;", -1)
-	title = strings.Replace(title, "<", "&lt;", -1)
-	title = strings.Replace(title, ">", "&gt;", -1)
-
-	output := fmt.Sprintf(" • <%s|#%d> %s - _%s_", p.WebLink, p.ID, title, p.Author)
-
-	if p.Approved {
-		output += ", *APPROVED*"
+// WaitingOn returns the requested reviewers that haven't approved the pull request yet, followed by
+// the requested teams
+func (p *PullRequest) WaitingOn() []string {
+	approved := make(map[string]bool)
+	for _, review := range p.Reviews {
+		if review.State == ReviewApproved {
+			approved[strings.ToLower(review.Reviewer)] = true
+		}
 	}
 
-	if p.Assignee != "" {
-		output += fmt.Sprintf(", assigned to _%s_", p.Assignee)
+	var waiting []string
+	for _, reviewer := range p.Reviewers {
+		if !approved[strings.ToLower(reviewer)] {
+			waiting = append(waiting, reviewer)
+		}
 	}
-	output += fmt.Sprintf(" - updated %s", humanize.Time(p.Updated))
-	return output
+	return append(waiting, p.ReviewerTeams...)
+}
+
+// escapeSlack escapes the characters that have a special meaning in Slack messages
+func escapeSlack(text string) string {
+	text = strings.Replace(text, "&", "&amp;", -1)
+	text = strings.Replace(text, "<", "&lt;", -1)
+	return strings.Replace(text, ">", "&gt;", -1)
 }
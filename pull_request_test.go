@@ -47,6 +47,18 @@ func TestPullRequest_String(t *testing.T) {
 			},
 			expected: " • <http://gitlab.local/243|#243> fixes bug - _john.doe_, assigned to _jane.doe_ - updated a long while ago",
 		},
+		{
+			pr: &PullRequest{
+				WebLink:       "http://gitlab.local/243",
+				ID:            243,
+				Title:         "fixes bug",
+				Author:        "john.doe",
+				Reviewers:     []string{"jane.doe", "joe"},
+				ReviewerTeams: []string{"acme/backend"},
+				Reviews:       []*Review{{Reviewer: "joe", State: ReviewApproved}},
+			},
+			expected: " • <http://gitlab.local/243|#243> fixes bug - _john.doe_, waiting on @jane.doe, @acme/backend - updated a long while ago",
+		},
 	}
 
 	for _, test := range tests {
@@ -55,3 +67,25 @@ func TestPullRequest_String(t *testing.T) {
 		}
 	}
 }
+
+func TestPullRequest_WaitingOn(t *testing.T) {
+	pr := &PullRequest{
+		Reviewers:     []string{"Jane", "joe", "john"},
+		ReviewerTeams: []string{"acme/backend"},
+		Reviews: []*Review{
+			{Reviewer: "jane", State: ReviewApproved},
+			{Reviewer: "joe", State: ReviewChangesRequested},
+			{Reviewer: "jim", State: ReviewApproved},
+		},
+	}
+	expected := []string{"joe", "john", "acme/backend"}
+	waiting := pr.WaitingOn()
+	if len(waiting) != len(expected) {
+		t.Fatalf("Expected to wait on %v, got %v", expected, waiting)
+	}
+	for i := range expected {
+		if waiting[i] != expected[i] {
+			t.Errorf("Expected to wait on %v, got %v", expected, waiting)
+		}
+	}
+}
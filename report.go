@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Report is the message with all pull requests grouped by their repository, it's rendered by the
+// different outputs
+type Report struct {
+	Repositories []*RepositoryReport
+	// Errors are the repositories and pull requests that could not be fetched
+	Errors []*FetchError
+	// NumFiltered is the number of pull requests that were removed by the filters
+	NumFiltered int
+}
+
+// RepositoryReport is the pull requests of a single repository
+type RepositoryReport struct {
+	Name         string         `json:"name"`
+	PullRequests []*PullRequest `json:"pull_requests"`
+}
+
+// NumPullRequests returns the total number of pull requests in the report
+func (r *Report) NumPullRequests() int {
+	num := 0
+	for _, repo := range r.Repositories {
+		num += len(repo.PullRequests)
+	}
+	return num
+}
+
+// Oldest returns the pull request that was updated the longest time ago, or nil if there are no
+// pull requests
+func (r *Report) Oldest() *PullRequest {
+	var oldest *PullRequest
+	for _, repo := range r.Repositories {
+		for _, pr := range repo.PullRequests {
+			if oldest == nil || pr.Updated.Before(oldest.Updated) {
+				oldest = pr
+			}
+		}
+	}
+	return oldest
+}
+
+// Summary returns the number of open pull requests, when the oldest was updated and how many pull
+// requests were filtered
+func (r *Report) Summary() string {
+	buf := &bytes.Buffer{}
+	if overview := r.Overview(); overview != "" {
+		fmt.Fprintf(buf, "%s\n", overview)
+	}
+	fmt.Fprintf(buf, "%d pull request(s) filtered from these results", r.NumFiltered)
+	return buf.String()
+}
+
+// Overview returns the number of open pull requests and when the oldest was updated, or an empty
+// string if there are no pull requests
+func (r *Report) Overview() string {
+	oldest := r.Oldest()
+	if oldest == nil {
+		return ""
+	}
+	return fmt.Sprintf("There are currently %d open pull requests and the oldest (<%s|PR #%d>) was updated %s",
+		r.NumPullRequests(), oldest.WebLink, oldest.ID, humanize.Time(oldest.Updated))
+}
+
+// String returns the report in the Slack mrkdwn format
+func (r *Report) String() string {
+	buf := &bytes.Buffer{}
+	for _, repo := range r.Repositories {
+		fmt.Fprintf(buf, "*%s*\n", repo.Name)
+		for _, pr := range repo.PullRequests {
+			fmt.Fprintf(buf, "%s\n", pr)
+		}
+		fmt.Fprint(buf, "\n")
+	}
+
+	if len(r.Errors) > 0 {
+		fmt.Fprint(buf, "*Could not be fetched*\n")
+		for _, err := range r.Errors {
+			fmt.Fprintf(buf, " • %s\n", escapeSlack(err.Error()))
+		}
+		fmt.Fprint(buf, "\n")
+	}
+
+	if r.NumPullRequests() > 0 {
+		fmt.Fprint(buf, "\n")
+	}
+	fmt.Fprintf(buf, "%s\n", r.Summary())
+	return buf.String()
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// slackMaxBlocks is the maximum number of blocks in a single Slack message
+	slackMaxBlocks = 50
+	// slackMaxTextLength is the maximum length of the text in a section block
+	slackMaxTextLength = 3000
+	// slackMaxContextLength is the maximum length of the text of an element in a context block
+	slackMaxContextLength = 2000
+	// slackMaxHeaderLength is the maximum length of the text in a header block
+	slackMaxHeaderLength = 150
+)
+
+// slackAPIURL is the base URL of the Slack Web API
+var slackAPIURL = "https://slack.com/api/"
+
+func init() {
+	RegisterNotifier("slack", newSlackNotifier)
+}
+
+// SlackNotifier posts the report to a Slack channel with a bot token, or to an incoming webhook.
+// With DirectMessages it instead sends each user only the pull requests that are waiting on them.
+type SlackNotifier struct {
+	Token          string
+	Channel        string
+	WebhookURL     string
+	DirectMessages bool
+	// Users maps the logins of the providers to Slack user IDs or email addresses
+	Users map[string]string
+	// Mentions is "all" to mention authors, assignees and reviewers that are in Users, "reviewers"
+	// to only mention reviewers, or empty to not mention anyone
+	Mentions string
+	// log reports the users that couldn't be looked up, nothing is logged if it's nil
+	log Logger
+}
+
+func newSlackNotifier(output *Output) Notifier {
+	return &SlackNotifier{
+		Token:          output.Token,
+		Channel:        output.Channel,
+		WebhookURL:     output.WebhookURL,
+		DirectMessages: output.DirectMessages,
+		Users:          output.Users,
+		Mentions:       output.Mentions,
+		log:            NewStdOutLogger(false),
+	}
+}
+
+// Name returns the name of the output
+func (n *SlackNotifier) Name() string {
+	return "Slack"
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (n *SlackNotifier) Validate() []error {
+	var errors []error
+	switch n.Mentions {
+	case "", slackMentionAll, slackMentionReviewers:
+	default:
+		errors = append(errors, fmt.Errorf("%s is not a valid Slack mentions setting, use '%s' or '%s'", n.Mentions, slackMentionAll, slackMentionReviewers))
+	}
+	// email addresses can only be looked up with a bot token
+	if n.Mentions != "" && n.Token == "" {
+		for login, user := range n.Users {
+			if strings.Contains(user, "@") {
+				errors = append(errors, fmt.Errorf("Slack user of %s must be a user ID to be mentioned without a Slack token", login))
+			}
+		}
+	}
+	// direct messages can only be sent with a bot token
+	if n.DirectMessages {
+		if n.Token == "" {
+			errors = append(errors, fmt.Errorf("Slack token cannot be empty for direct messages"))
+		}
+		if n.WebhookURL != "" {
+			errors = append(errors, fmt.Errorf("Slack direct messages cannot be sent to a webhook"))
+		}
+		if len(n.Users) == 0 {
+			errors = append(errors, fmt.Errorf("Slack users cannot be empty for direct messages"))
+		}
+		return errors
+	}
+	// messages are either sent with a bot token to a channel, or to an incoming webhook
+	if n.WebhookURL != "" {
+		if n.Token != "" {
+			errors = append(errors, fmt.Errorf("Slack token and Slack webhook URL cannot both be configured"))
+		}
+		// a relative URL would only fail when the message is sent
+		if u, err := url.Parse(n.WebhookURL); err != nil {
+			errors = append(errors, fmt.Errorf("%s is not a valid Slack webhook URL: %v", n.WebhookURL, err))
+		} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errors = append(errors, fmt.Errorf("%s is not a valid Slack webhook URL: it must be an absolute http or https URL", n.WebhookURL))
+		}
+		return errors
+	}
+	if n.Token == "" {
+		errors = append(errors, fmt.Errorf("Slack token cannot be empty"))
+	}
+	if n.Channel == "" {
+		errors = append(errors, fmt.Errorf("Slack channel cannot be empty"))
+	}
+	return errors
+}
+
+// slackMessage is a message for the chat.postMessage Slack API method
+type slackMessage struct {
+	Channel   string        `json:"channel,omitempty"`
+	Username  string        `json:"username,omitempty"`
+	IconEmoji string        `json:"icon_emoji,omitempty"`
+	Text      string        `json:"text"`
+	Blocks    []*slackBlock `json:"blocks"`
+}
+
+// slackResponse is the part of a Slack Web API response that says whether the call succeeded
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// slackBlock is a Block Kit layout block, see https://api.slack.com/reference/block-kit/blocks
+type slackBlock struct {
+	Type     string       `json:"type"`
+	Text     *slackText   `json:"text,omitempty"`
+	Elements []*slackText `json:"elements,omitempty"`
+}
+
+// slackText is a Block Kit text object
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Notify will post the report to Slack, either to an incoming webhook or with a bot token. A
+// report with more blocks than fits in a single message is divided into several messages, which
+// are split between repositories.
+func (n *SlackNotifier) Notify(ctx context.Context, report *Report) error {
+	client := defaultClient
+	if n.Token != "" {
+		client = newTokenClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: n.Token}))
+	}
+	mentions := n.mentions(ctx, client, report)
+
+	if n.WebhookURL != "" {
+		return postToSlackWebhook(ctx, n.WebhookURL, slackMessages(report, mentions))
+	}
+	if n.DirectMessages {
+		return n.notifyUsers(ctx, client, report, mentions)
+	}
+	return postSlackMessages(ctx, client, n.Channel, slackMessages(report, mentions))
+}
+
+// postSlackMessages posts the messages to a channel, or to the direct messages with the app if the
+// channel is a user ID
+func postSlackMessages(ctx context.Context, client *http.Client, channel string, messages []*slackMessage) error {
+	for _, message := range messages {
+		message.Channel = channel
+		message.Username = "purr"
+		message.IconEmoji = ":purr:"
+
+		result := &slackResponse{}
+		if err := postJSON(ctx, client, slackAPIURL+"chat.postMessage", message, result); err != nil {
+			return err
+		}
+		// the Slack API responds with 200 OK for most errors
+		if !result.OK {
+			return fmt.Errorf("chat.postMessage: %s", result.Error)
+		}
+	}
+	return nil
+}
+
+// postToSlackWebhook posts the messages to a Slack incoming webhook, the webhook decides which
+// channel the messages are sent to and who they are sent by
+func postToSlackWebhook(ctx context.Context, webhookURL string, messages []*slackMessage) error {
+	for _, message := range messages {
+		// incoming webhooks respond with a plain text "ok" and an error status for any errors
+		if err := postJSON(ctx, defaultClient, webhookURL, message, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slackMessages renders the report as Block Kit messages, each with at most slackMaxBlocks blocks.
+// Users are mentioned if mentions isn't nil.
+func slackMessages(report *Report, mentions *slackMentions) []*slackMessage {
+	messages := slackReportMessages("Open pull requests", report.Summary(), report, mentions)
+	// the summary is the notification text of the last message
+	messages[len(messages)-1].Text = report.Summary()
+	return messages
+}
+
+// slackReportMessages renders the report as Block Kit messages with the title as the header and
+// the notification text, and the summary at the end
+func slackReportMessages(title, summary string, report *Report, mentions *slackMentions) []*slackMessage {
+	header := &slackBlock{
+		Type: "header",
+		Text: &slackText{Type: "plain_text", Text: truncate(title, slackMaxHeaderLength)},
+	}
+	messages := []*slackMessage{{Text: header.Text.Text, Blocks: []*slackBlock{header}}}
+	current := messages[0]
+
+	// add starts a new message if the blocks don't fit into the current one
+	add := func(blocks ...*slackBlock) {
+		if len(current.Blocks) > 0 && len(current.Blocks)+len(blocks) > slackMaxBlocks {
+			current = &slackMessage{Text: header.Text.Text}
+			messages = append(messages, current)
+		}
+		current.Blocks = append(current.Blocks, blocks...)
+	}
+
+	// a repository with more pull requests than fits in a message is split into chunks that each
+	// fit in a message together with the header, the repository name and a divider
+	const chunkSize = (slackMaxBlocks - 3) / 2
+	for _, repo := range report.Repositories {
+		for start := 0; start < len(repo.PullRequests); start += chunkSize {
+			end := start + chunkSize
+			if end > len(repo.PullRequests) {
+				end = len(repo.PullRequests)
+			}
+			name := fmt.Sprintf("*%s*", escapeSlack(repo.Name))
+			if start > 0 {
+				name += " (continued)"
+			}
+
+			blocks := []*slackBlock{slackSection(name)}
+			for _, pr := range repo.PullRequests[start:end] {
+				blocks = append(blocks, slackPullRequestBlocks(pr, mentions)...)
+			}
+			blocks = append(blocks, &slackBlock{Type: "divider"})
+			add(blocks...)
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		lines := []string{"*Could not be fetched*"}
+		for _, err := range report.Errors {
+			lines = append(lines, fmt.Sprintf(" • %s", escapeSlack(err.Error())))
+		}
+		add(slackSection(strings.Join(lines, "\n")))
+	}
+
+	if summary != "" {
+		add(slackContext(summary))
+	}
+	return messages
+}
+
+// slackPullRequestBlocks returns a section with the title of the pull request and a context with
+// the author, assignee, approval, the reviewers it's waiting on and age
+func slackPullRequestBlocks(pr *PullRequest, mentions *slackMentions) []*slackBlock {
+	details := []string{mentions.user(pr.Author)}
+	if pr.Approved {
+		details = append(details, "*APPROVED*")
+	}
+	if pr.Assignee != "" {
+		details = append(details, fmt.Sprintf("assigned to %s", mentions.user(pr.Assignee)))
+	}
+	if waiting := pr.WaitingOn(); len(waiting) > 0 {
+		var reviewers []string
+		for _, reviewer := range waiting {
+			reviewers = append(reviewers, mentions.reviewer(reviewer))
+		}
+		details = append(details, fmt.Sprintf("waiting on %s", strings.Join(reviewers, ", ")))
+	}
+	details = append(details, fmt.Sprintf("updated %s", humanize.Time(pr.Updated)))
+
+	return []*slackBlock{
+		slackSection(fmt.Sprintf("<%s|#%d> %s", pr.WebLink, pr.ID, escapeSlack(pr.Title))),
+		slackContext(strings.Join(details, " · ")),
+	}
+}
+
+// slackSection returns a section block with mrkdwn text
+func slackSection(text string) *slackBlock {
+	return &slackBlock{
+		Type: "section",
+		Text: &slackText{Type: "mrkdwn", Text: truncate(text, slackMaxTextLength)},
+	}
+}
+
+// slackContext returns a context block with a single mrkdwn element
+func slackContext(text string) *slackBlock {
+	return &slackBlock{
+		Type:     "context",
+		Elements: []*slackText{{Type: "mrkdwn", Text: truncate(text, slackMaxContextLength)}},
+	}
+}
+
+// truncate shortens the text to at most max characters, ending it with an ellipsis if it was too
+// long
+func truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	return string([]rune(text)[:max-1]) + "…"
+}
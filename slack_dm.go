@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// notifyUsers sends each Slack user a direct message with the pull requests that are waiting on
+// them. Pull requests of logins that aren't mapped to a Slack user are left out. A failed message
+// doesn't stop the other users from getting theirs.
+func (n *SlackNotifier) notifyUsers(ctx context.Context, client *http.Client, report *Report, mentions *slackMentions) error {
+	var failed []string
+	for _, user := range n.userReports(report) {
+		id, err := lookupSlackUser(ctx, client, user.slackUser)
+		if err == nil {
+			// the pull requests of a user aren't filtered, so the summary leaves out the filtered count
+			messages := slackReportMessages("Pull requests waiting on you", user.report.Overview(), user.report, mentions)
+			err = postSlackMessages(ctx, client, id, messages)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", user.slackUser, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("could not send direct messages to %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// slackUserReport is the report with the pull requests that are waiting on a Slack user
+type slackUserReport struct {
+	// slackUser is a Slack user ID or an email address
+	slackUser string
+	report    *Report
+}
+
+// userReports returns a report for each Slack user with the pull requests that are waiting on them,
+// grouped by repository in the same order as the report
+func (n *SlackNotifier) userReports(report *Report) []*slackUserReport {
+	// logins are case insensitive on GitHub and GitLab
+	users := make(map[string]string)
+	for login, slackUser := range n.Users {
+		users[strings.ToLower(login)] = slackUser
+	}
+
+	reports := make(map[string]*Report)
+	for _, repo := range report.Repositories {
+		for _, pr := range repo.PullRequests {
+			// several logins can belong to the same Slack user
+			added := make(map[string]bool)
+			for _, login := range slackRecipients(pr) {
+				slackUser, ok := users[strings.ToLower(login)]
+				if !ok || added[slackUser] {
+					continue
+				}
+				added[slackUser] = true
+				if _, ok := reports[slackUser]; !ok {
+					reports[slackUser] = &Report{}
+				}
+				userReport := reports[slackUser]
+				// the pull requests are in order, so the repository is the last one if it has been added
+				last := len(userReport.Repositories) - 1
+				if last < 0 || userReport.Repositories[last].Name != repo.Name {
+					userReport.Repositories = append(userReport.Repositories, &RepositoryReport{Name: repo.Name})
+					last++
+				}
+				userReport.Repositories[last].PullRequests = append(userReport.Repositories[last].PullRequests, pr)
+			}
+		}
+	}
+
+	var userReports []*slackUserReport
+	for slackUser, userReport := range reports {
+		userReports = append(userReports, &slackUserReport{slackUser: slackUser, report: userReport})
+	}
+	sort.Slice(userReports, func(i, j int) bool {
+		return userReports[i].slackUser < userReports[j].slackUser
+	})
+	return userReports
+}
+
+// slackRecipients returns the logins that are sent the pull request in a direct message: the
+// reviewers it's waiting on and the assignee. Authors aren't sent their own pull requests, they
+// already know about them.
+func slackRecipients(pr *PullRequest) []string {
+	logins := pr.WaitingOn()
+	if pr.Assignee != "" {
+		logins = append(logins, pr.Assignee)
+	}
+
+	seen := make(map[string]bool)
+	var unique []string
+	for _, login := range logins {
+		if !seen[strings.ToLower(login)] {
+			seen[strings.ToLower(login)] = true
+			unique = append(unique, login)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlackNotifier_NotifyUsers(t *testing.T) {
+	messages := make(map[string][]*slackMessage)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users.lookupByEmail":
+			if r.URL.Query().Get("email") != "joe@example.com" {
+				fmt.Fprint(w, `{"ok": false, "error": "users_not_found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"ok": true, "user": {"id": "U002"}}`)
+		case "/chat.postMessage":
+			message := &slackMessage{}
+			if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+				t.Error(err)
+			}
+			messages[message.Channel] = append(messages[message.Channel], message)
+			fmt.Fprint(w, `{"ok": true}`)
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { slackAPIURL = url }(slackAPIURL)
+	slackAPIURL = server.URL + "/"
+
+	report := newSlackReport(2, 1)
+	// waiting on the reviewer jane and the assignee joe
+	report.Repositories[0].PullRequests[0].Reviewers = []string{"Jane"}
+	report.Repositories[0].PullRequests[0].Assignee = "joe"
+	// jane is the author, so they aren't sent it even though it needs changes
+	report.Repositories[0].PullRequests[1].RequiresChanges = true
+	// nobody with a Slack user is waiting on it
+	report.Repositories[1].PullRequests[0].Reviewers = []string{"unknown"}
+
+	notifier := &SlackNotifier{
+		Token:          "secret",
+		DirectMessages: true,
+		Users:          map[string]string{"jane": "U001", "joe": "joe@example.com"},
+	}
+	if errs := notifier.Validate(); len(errs) != 0 {
+		t.Fatalf("Did not expect validation errors, got %v", errs)
+	}
+	if err := notifier.Notify(context.Background(), report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("Expected direct messages to 2 users, got %d", len(messages))
+	}
+	expected := map[string][]string{
+		"U001": {"/acme/repo0/pull/1"},
+		"U002": {"/acme/repo0/pull/1"},
+	}
+	for user, links := range expected {
+		if len(messages[user]) != 1 {
+			t.Errorf("Expected 1 message to %s, got %d", user, len(messages[user]))
+			continue
+		}
+		data, _ := json.Marshal(messages[user][0])
+		for _, link := range links {
+			if !strings.Contains(string(data), link) {
+				t.Errorf("Expected the message to %s to contain %s, got %s", user, link, data)
+			}
+		}
+		if strings.Contains(string(data), "/acme/repo0/pull/2") || strings.Contains(string(data), "acme/repo1") {
+			t.Errorf("Expected the message to %s to only contain the pull requests waiting on them, got %s", user, data)
+		}
+		if messages[user][0].Blocks[0].Text.Text != "Pull requests waiting on you" {
+			t.Errorf("Expected the heading to be 'Pull requests waiting on you', got '%s'", messages[user][0].Blocks[0].Text.Text)
+		}
+		if messages[user][0].Text != "Pull requests waiting on you" {
+			t.Errorf("Expected the notification text to be 'Pull requests waiting on you', got '%s'", messages[user][0].Text)
+		}
+		if strings.Contains(string(data), "filtered") {
+			t.Errorf("Expected the message to %s to leave out the filtered pull requests, got %s", user, data)
+		}
+	}
+
+	// a user that can't be found doesn't stop the other messages
+	messages = make(map[string][]*slackMessage)
+	notifier.Users["joe"] = "joe@example.org"
+	if err := notifier.Notify(context.Background(), report); err == nil || !strings.Contains(err.Error(), "users_not_found") {
+		t.Errorf("Expected a users_not_found error, got %v", err)
+	}
+	if len(messages["U001"]) != 1 {
+		t.Errorf("Expected the message to U001 to be sent, got %d", len(messages["U001"]))
+	}
+}
+
+func TestSlackNotifier_ValidateDirectMessages(t *testing.T) {
+	notifier := &SlackNotifier{DirectMessages: true, WebhookURL: "https://hooks.slack.com/services/T000/B000/XXXX"}
+	if errs := notifier.Validate(); len(errs) != 3 {
+		t.Errorf("Expected 3 validation errors, got %d: %v", len(errs), errs)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSlackReport returns a report with a repository for each number of pull requests
+func newSlackReport(numPRs ...int) *Report {
+	report := &Report{}
+	for i, num := range numPRs {
+		repo := &RepositoryReport{Name: fmt.Sprintf("acme/repo%d", i)}
+		for id := 1; id <= num; id++ {
+			repo.PullRequests = append(repo.PullRequests, &PullRequest{
+				ID:         id,
+				Author:     "jane",
+				Title:      "fix <script>",
+				WebLink:    fmt.Sprintf("https://github.com/acme/repo%d/pull/%d", i, id),
+				Repository: repo.Name,
+				Updated:    time.Now(),
+			})
+		}
+		report.Repositories = append(report.Repositories, repo)
+	}
+	return report
+}
+
+func TestSlackMessages_SplitOnRepositories(t *testing.T) {
+	// each repository needs 42 blocks, so only one fits in each message
+	messages := slackMessages(newSlackReport(20, 20, 20), nil)
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	for i, message := range messages {
+		if len(message.Blocks) > slackMaxBlocks {
+			t.Errorf("Expected message %d to have at most %d blocks, got %d", i, slackMaxBlocks, len(message.Blocks))
+		}
+		repo := fmt.Sprintf("*acme/repo%d*", i)
+		first := message.Blocks[0]
+		if i == 0 {
+			if first.Type != "header" {
+				t.Errorf("Expected the first message to start with a header, got %s", first.Type)
+			}
+			first = message.Blocks[1]
+		}
+		if first.Text.Text != repo {
+			t.Errorf("Expected message %d to start with %s, got %s", i, repo, first.Text.Text)
+		}
+	}
+	last := messages[2].Blocks[len(messages[2].Blocks)-1]
+	if last.Type != "context" || !strings.Contains(last.Elements[0].Text, "There are currently 60 open pull requests") {
+		t.Errorf("Expected the last message to end with the summary, got %+v", last)
+	}
+}
+
+func TestSlackMessages_SplitLargeRepository(t *testing.T) {
+	messages := slackMessages(newSlackReport(60), nil)
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	if messages[1].Blocks[0].Text.Text != "*acme/repo0* (continued)" {
+		t.Errorf("Expected the second message to continue the repository, got %s", messages[1].Blocks[0].Text.Text)
+	}
+	for i, message := range messages {
+		if len(message.Blocks) > slackMaxBlocks {
+			t.Errorf("Expected message %d to have at most %d blocks, got %d", i, slackMaxBlocks, len(message.Blocks))
+		}
+	}
+}
+
+func TestSlackMessages_Escaping(t *testing.T) {
+	report := newSlackReport(1)
+	report.Repositories[0].PullRequests[0].Title = strings.Repeat("a", 4000)
+	messages := slackMessages(newSlackReport(1), nil)
+	pr := messages[0].Blocks[2].Text.Text
+	if !strings.HasSuffix(pr, "fix &lt;script&gt;") {
+		t.Errorf("Expected the title to be escaped, got %s", pr)
+	}
+
+	messages = slackMessages(report, nil)
+	if length := len([]rune(messages[0].Blocks[2].Text.Text)); length != slackMaxTextLength {
+		t.Errorf("Expected a long title to be truncated to %d characters, got %d", slackMaxTextLength, length)
+	}
+}
+
+func TestSlackNotifier_Notify(t *testing.T) {
+	var messages []*slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat.postMessage" {
+			t.Errorf("Expected a request to /chat.postMessage, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected the Slack token, got '%s'", r.Header.Get("Authorization"))
+		}
+		message := &slackMessage{}
+		if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+			t.Error(err)
+		}
+		messages = append(messages, message)
+		fmt.Fprint(w, `{"ok": true}`)
+	}))
+	defer server.Close()
+
+	defer func(url string) { slackAPIURL = url }(slackAPIURL)
+	slackAPIURL = server.URL + "/"
+
+	notifier := &SlackNotifier{Token: "secret", Channel: "team"}
+	if err := notifier.Notify(context.Background(), newSlackReport(20, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Channel != "team" || messages[0].Username != "purr" {
+		t.Errorf("Expected the message to be sent to 'team' as 'purr', got %+v", messages[0])
+	}
+}
+
+func TestSlackNotifier_NotifyError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok": false, "error": "channel_not_found"}`)
+	}))
+	defer server.Close()
+
+	defer func(url string) { slackAPIURL = url }(slackAPIURL)
+	slackAPIURL = server.URL + "/"
+
+	notifier := &SlackNotifier{Token: "secret", Channel: "team"}
+	if err := notifier.Notify(context.Background(), newSlackReport(1)); err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Errorf("Expected a channel_not_found error, got %v", err)
+	}
+}
+
+func TestSlackNotifier_NotifyWebhook(t *testing.T) {
+	var messages []*slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/services/T000/B000/XXXX" {
+			t.Errorf("Expected a request to the webhook, got %s", r.URL.Path)
+		}
+		message := &slackMessage{}
+		if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+			t.Error(err)
+		}
+		messages = append(messages, message)
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	notifier := &SlackNotifier{WebhookURL: server.URL + "/services/T000/B000/XXXX"}
+	if err := notifier.Notify(context.Background(), newSlackReport(20, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Channel != "" {
+		t.Errorf("Expected the webhook to decide the channel, got '%s'", messages[0].Channel)
+	}
+}
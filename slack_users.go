@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// slackMentionAll mentions the authors, assignees and requested reviewers of pull requests
+	slackMentionAll = "all"
+	// slackMentionReviewers only mentions the requested reviewers of pull requests
+	slackMentionReviewers = "reviewers"
+)
+
+// slackMentions renders the logins of the providers as mentions of the Slack users they belong to
+type slackMentions struct {
+	// ids maps lower case logins to Slack user IDs
+	ids map[string]string
+	// onlyReviewers is set if authors and assignees aren't mentioned
+	onlyReviewers bool
+}
+
+// user returns a mention of the Slack user of an author or assignee, or the login in italics if the
+// user isn't known or shouldn't be mentioned. A nil slackMentions never mentions anyone.
+func (m *slackMentions) user(login string) string {
+	if m != nil && !m.onlyReviewers {
+		if id, ok := m.ids[strings.ToLower(login)]; ok {
+			return fmt.Sprintf("<@%s>", id)
+		}
+	}
+	return fmt.Sprintf("_%s_", escapeSlack(login))
+}
+
+// reviewer returns a mention of the Slack user of a reviewer, or @login if the user isn't known
+func (m *slackMentions) reviewer(login string) string {
+	if m != nil {
+		if id, ok := m.ids[strings.ToLower(login)]; ok {
+			return fmt.Sprintf("<@%s>", id)
+		}
+	}
+	return "@" + escapeSlack(login)
+}
+
+// mentions returns the Slack user IDs of the users in the report that are mentioned, or nil if
+// mentions are disabled. Users with an email address are looked up, and a user that can't be found
+// is logged and shown with their login instead of failing the whole report.
+func (n *SlackNotifier) mentions(ctx context.Context, client *http.Client, report *Report) *slackMentions {
+	if n.Mentions == "" {
+		return nil
+	}
+	mentions := &slackMentions{ids: make(map[string]string), onlyReviewers: n.Mentions == slackMentionReviewers}
+
+	users := make(map[string]string)
+	for login, user := range n.Users {
+		users[strings.ToLower(login)] = user
+	}
+
+	// each user is only looked up once, even if they are in many pull requests
+	ids := make(map[string]string)
+	resolve := func(login string) {
+		user, ok := users[strings.ToLower(login)]
+		if !ok {
+			return
+		}
+		if _, ok := ids[user]; !ok {
+			// the ID is empty if the user couldn't be found
+			id, err := lookupSlackUser(ctx, client, user)
+			if err != nil && n.log != nil {
+				n.log.Infof("Couldn't look up the Slack user of %s (%s): %s\n", login, user, err)
+			}
+			ids[user] = id
+		}
+		if ids[user] != "" {
+			mentions.ids[strings.ToLower(login)] = ids[user]
+		}
+	}
+
+	for _, repo := range report.Repositories {
+		for _, pr := range repo.PullRequests {
+			if !mentions.onlyReviewers {
+				resolve(pr.Author)
+				resolve(pr.Assignee)
+			}
+			for _, reviewer := range pr.WaitingOn() {
+				resolve(reviewer)
+			}
+		}
+	}
+	return mentions
+}
+
+// lookupSlackUser returns the ID of a Slack user, which is either the user itself or looked up by
+// email address with users.lookupByEmail
+func lookupSlackUser(ctx context.Context, client *http.Client, user string) (string, error) {
+	if !strings.Contains(user, "@") {
+		return user, nil
+	}
+	result := &struct {
+		slackResponse
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}{}
+	if err := getJSON(ctx, client, slackAPIURL+"users.lookupByEmail?email="+url.QueryEscape(user), result); err != nil {
+		return "", err
+	}
+	if !result.OK {
+		return "", fmt.Errorf("users.lookupByEmail: %s", result.Error)
+	}
+	return result.User.ID, nil
+}
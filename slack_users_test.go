@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlackMessages_Mentions(t *testing.T) {
+	report := newSlackReport(1)
+	pr := report.Repositories[0].PullRequests[0]
+	pr.Assignee = "Joe"
+	pr.Reviewers = []string{"joe", "john"}
+
+	tests := []struct {
+		mentions *slackMentions
+		expected string
+	}{
+		{nil, "_jane_ · assigned to _Joe_ · waiting on @joe, @john · updated"},
+		{&slackMentions{ids: map[string]string{"jane": "U001", "joe": "U002"}}, "<@U001> · assigned to <@U002> · waiting on <@U002>, @john · updated"},
+		{&slackMentions{ids: map[string]string{"jane": "U001", "joe": "U002"}, onlyReviewers: true}, "_jane_ · assigned to _Joe_ · waiting on <@U002>, @john · updated"},
+	}
+	for _, test := range tests {
+		blocks := slackMessages(report, test.mentions)[0].Blocks
+		context := blocks[3].Elements[0].Text
+		if len(context) < len(test.expected) || context[:len(test.expected)] != test.expected {
+			t.Errorf("Expected the details to start with\n%s\ngot\n%s", test.expected, context)
+		}
+	}
+}
+
+func TestSlackNotifier_Mentions(t *testing.T) {
+	lookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		switch r.URL.Query().Get("email") {
+		case "joe@example.com":
+			fmt.Fprint(w, `{"ok": true, "user": {"id": "U002"}}`)
+		default:
+			fmt.Fprint(w, `{"ok": false, "error": "users_not_found"}`)
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { slackAPIURL = url }(slackAPIURL)
+	slackAPIURL = server.URL + "/"
+
+	report := newSlackReport(3)
+	for _, pr := range report.Repositories[0].PullRequests {
+		pr.Reviewers = []string{"joe", "gone"}
+	}
+
+	notifier := &SlackNotifier{
+		Token:    "secret",
+		Channel:  "team",
+		Mentions: slackMentionAll,
+		Users:    map[string]string{"jane": "U001", "joe": "joe@example.com", "gone": "gone@example.com"},
+		log:      &recordingLogger{},
+	}
+	if errs := notifier.Validate(); len(errs) != 0 {
+		t.Fatalf("Did not expect validation errors, got %v", errs)
+	}
+	mentions := notifier.mentions(context.Background(), defaultClient, report)
+	if lookups != 2 {
+		t.Errorf("Expected each email address to be looked up once, got %d lookups", lookups)
+	}
+	// gone can't be found, so they are shown with their login
+	if len(mentions.ids) != 2 || mentions.ids["jane"] != "U001" || mentions.ids["joe"] != "U002" {
+		t.Errorf("Expected jane and joe to be mentioned, got %v", mentions.ids)
+	}
+	if logged := notifier.log.(*recordingLogger).messages; len(logged) != 1 || !strings.Contains(logged[0], "users_not_found") {
+		t.Errorf("Expected the failed lookup of gone to be logged once, got %v", logged)
+	}
+}
+
+// recordingLogger keeps the messages that are logged
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Infof(format string, a ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, a...))
+}
+
+func (l *recordingLogger) Debugf(format string, a ...interface{}) {}
+
+func TestSlackNotifier_ValidateMentions(t *testing.T) {
+	notifier := &SlackNotifier{
+		WebhookURL: "https://hooks.slack.com/services/T000/B000/XXXX",
+		Mentions:   "everyone",
+		Users:      map[string]string{"jane": "U001", "joe": "joe@example.com"},
+	}
+	if errs := notifier.Validate(); len(errs) != 2 {
+		t.Errorf("Expected 2 validation errors, got %d: %v", len(errs), errs)
+	}
+
+	// email addresses can't be looked up without a token
+	notifier.Mentions = slackMentionReviewers
+	if errs := notifier.Validate(); len(errs) != 1 {
+		t.Errorf("Expected 1 validation error, got %d: %v", len(errs), errs)
+	}
+}
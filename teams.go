@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+func init() {
+	RegisterNotifier("teams", newTeamsNotifier)
+}
+
+// TeamsNotifier posts the report to a Microsoft Teams incoming webhook
+type TeamsNotifier struct {
+	WebhookURL string
+}
+
+func newTeamsNotifier(output *Output) Notifier {
+	return &TeamsNotifier{WebhookURL: output.WebhookURL}
+}
+
+// Name returns the name of the output
+func (n *TeamsNotifier) Name() string {
+	return "Microsoft Teams"
+}
+
+// Validate returns a list of errors for any invalid configuration
+func (n *TeamsNotifier) Validate() []error {
+	var errors []error
+	if _, err := url.ParseRequestURI(n.WebhookURL); err != nil {
+		errors = append(errors, fmt.Errorf("%s is not a valid Microsoft Teams webhook URL: %v", n.WebhookURL, err))
+	}
+	return errors
+}
+
+// teamsMaxCardSize is the approximate number of bytes of card elements per message, Teams rejects
+// incoming webhook messages that are larger than 28 KB
+const teamsMaxCardSize = 20000
+
+// teamsMessage is a message with an Adaptive Card for a Microsoft Teams incoming webhook
+type teamsMessage struct {
+	Type        string             `json:"type"`
+	Attachments []*teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string     `json:"contentType"`
+	Content     *teamsCard `json:"content"`
+}
+
+// teamsCard is an Adaptive Card, see https://adaptivecards.io/explorer/AdaptiveCard.html
+type teamsCard struct {
+	Schema  string            `json:"$schema"`
+	Type    string            `json:"type"`
+	Version string            `json:"version"`
+	Body    []*teamsTextBlock `json:"body"`
+	MSTeams map[string]string `json:"msteams,omitempty"`
+}
+
+// teamsTextBlock is an Adaptive Card TextBlock element
+type teamsTextBlock struct {
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	Wrap      bool   `json:"wrap"`
+	Size      string `json:"size,omitempty"`
+	Weight    string `json:"weight,omitempty"`
+	Color     string `json:"color,omitempty"`
+	IsSubtle  bool   `json:"isSubtle,omitempty"`
+	Spacing   string `json:"spacing,omitempty"`
+	Separator bool   `json:"separator,omitempty"`
+}
+
+// Notify posts the report as one or more Adaptive Cards to the Microsoft Teams incoming webhook
+func (n *TeamsNotifier) Notify(ctx context.Context, report *Report) error {
+	for _, card := range teamsCards(report) {
+		message := &teamsMessage{
+			Type: "message",
+			Attachments: []*teamsAttachment{{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content:     card,
+			}},
+		}
+		if err := postJSON(ctx, defaultClient, n.WebhookURL, message, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// teamsCards renders the report as Adaptive Cards. A report that is too large for a single message
+// is split into several cards, a repository that continues on the next card has its name repeated.
+func teamsCards(report *Report) []*teamsCard {
+	newCard := func() *teamsCard {
+		return &teamsCard{
+			Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+			Type:    "AdaptiveCard",
+			Version: "1.4",
+			// use the full width of the chat instead of a narrow card
+			MSTeams: map[string]string{"width": "Full"},
+		}
+	}
+
+	current := newCard()
+	current.Body = append(current.Body, &teamsTextBlock{Type: "TextBlock", Text: "Open pull requests", Wrap: true, Size: "Large", Weight: "Bolder"})
+	cards := []*teamsCard{current}
+	size := 0
+
+	// add starts a new card if the elements don't fit in the current one and returns true if it did
+	add := func(elements ...*teamsTextBlock) bool {
+		data, _ := json.Marshal(elements)
+		started := false
+		if size > 0 && size+len(data) > teamsMaxCardSize {
+			current = newCard()
+			cards = append(cards, current)
+			size = 0
+			started = true
+		}
+		current.Body = append(current.Body, elements...)
+		size += len(data)
+		return started
+	}
+
+	for _, repo := range report.Repositories {
+		name := &teamsTextBlock{Type: "TextBlock", Text: escapeTeams(repo.Name), Wrap: true, Weight: "Bolder", Separator: true}
+		for i, pr := range repo.PullRequests {
+			elements := teamsPullRequestBlocks(pr)
+			// the name is added together with the first pull request so it's never the last element
+			// of a card
+			if i == 0 {
+				elements = append([]*teamsTextBlock{name}, elements...)
+			}
+			if add(elements...) && i > 0 {
+				// the pull request was added to a new card, so the repository name goes before it
+				continued := *name
+				continued.Text += " (continued)"
+				current.Body = append([]*teamsTextBlock{&continued}, current.Body...)
+			}
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		lines := []string{"**Could not be fetched**"}
+		for _, err := range report.Errors {
+			lines = append(lines, fmt.Sprintf("- %s", escapeTeams(err.Error())))
+		}
+		add(&teamsTextBlock{Type: "TextBlock", Text: strings.Join(lines, "\n"), Wrap: true, Color: "Attention", Separator: true})
+	}
+
+	summary := fmt.Sprintf("%d pull request(s) filtered from these results", report.NumFiltered)
+	if oldest := report.Oldest(); oldest != nil {
+		summary = fmt.Sprintf("There are currently %d open pull requests and the oldest ([PR #%d](%s)) was updated %s. %s",
+			report.NumPullRequests(), oldest.ID, oldest.WebLink, humanize.Time(oldest.Updated), summary)
+	}
+	add(&teamsTextBlock{Type: "TextBlock", Text: summary, Wrap: true, IsSubtle: true, Separator: true})
+	return cards
+}
+
+// teamsPullRequestBlocks returns a text block with a link to the pull request and a subtle text
+// block with the author, assignee, approval, the reviewers it's waiting on and age
+func teamsPullRequestBlocks(pr *PullRequest) []*teamsTextBlock {
+	details := []string{escapeTeams(pr.Author)}
+	if pr.Approved {
+		details = append(details, "**APPROVED**")
+	}
+	if pr.Assignee != "" {
+		details = append(details, fmt.Sprintf("assigned to %s", escapeTeams(pr.Assignee)))
+	}
+	if waiting := pr.WaitingOn(); len(waiting) > 0 {
+		var reviewers []string
+		for _, reviewer := range waiting {
+			reviewers = append(reviewers, "@"+escapeTeams(reviewer))
+		}
+		details = append(details, fmt.Sprintf("waiting on %s", strings.Join(reviewers, ", ")))
+	}
+	details = append(details, fmt.Sprintf("updated %s", humanize.Time(pr.Updated)))
+
+	return []*teamsTextBlock{
+		{Type: "TextBlock", Text: fmt.Sprintf("[#%d](%s) %s", pr.ID, pr.WebLink, escapeTeams(pr.Title)), Wrap: true},
+		{Type: "TextBlock", Text: strings.Join(details, " · "), Wrap: true, IsSubtle: true, Spacing: "None"},
+	}
+}
+
+// escapeTeams escapes the characters that have a special meaning in the markdown of Adaptive Cards
+func escapeTeams(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, "`", "\\`")
+	return replacer.Replace(text)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTeamsNotifier_Notify(t *testing.T) {
+	var messages []*teamsMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message := &teamsMessage{}
+		if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+			t.Error(err)
+		}
+		messages = append(messages, message)
+		fmt.Fprint(w, "1")
+	}))
+	defer server.Close()
+
+	report := newSlackReport(2)
+	report.Errors = []*FetchError{{Provider: "GitHub", Key: "acme/denied", Err: fmt.Errorf("403 Forbidden")}}
+	notifier := &TeamsNotifier{WebhookURL: server.URL}
+	if err := notifier.Notify(context.Background(), report); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	attachment := messages[0].Attachments[0]
+	if attachment.ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("Expected an Adaptive Card, got %s", attachment.ContentType)
+	}
+
+	var texts []string
+	for _, block := range attachment.Content.Body {
+		texts = append(texts, block.Text)
+	}
+	expected := []string{
+		"Open pull requests",
+		"acme/repo0",
+		"[#1](https://github.com/acme/repo0/pull/1) fix <script>",
+	}
+	for i := range expected {
+		if texts[i] != expected[i] {
+			t.Errorf("Expected element %d to be '%s', got '%s'", i, expected[i], texts[i])
+		}
+	}
+	if !strings.Contains(strings.Join(texts, "\n"), "- GitHub acme/denied: 403 Forbidden") {
+		t.Errorf("Expected the card to contain the repositories that could not be fetched, got %v", texts)
+	}
+}
+
+func TestTeamsCards_Split(t *testing.T) {
+	cards := teamsCards(newSlackReport(150))
+	if len(cards) < 2 {
+		t.Fatalf("Expected the report to be split into several cards, got %d", len(cards))
+	}
+	for i, card := range cards {
+		data, err := json.Marshal(card)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 28*1024 {
+			t.Errorf("Expected card %d to be smaller than 28 KB, got %d bytes", i, len(data))
+		}
+	}
+	if cards[1].Body[0].Text != "acme/repo0 (continued)" {
+		t.Errorf("Expected the second card to continue the repository, got '%s'", cards[1].Body[0].Text)
+	}
+}
+
+func TestEscapeTeams(t *testing.T) {
+	if escaped := escapeTeams("[WIP] fix *all* the_things"); escaped != `\[WIP\] fix \*all\* the\_things` {
+		t.Errorf("Expected markdown to be escaped, got '%s'", escaped)
+	}
+}